@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"examtopics-downloader/internal/diff"
+	"examtopics-downloader/internal/export"
+)
+
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonFlag := fs.Bool("json", false, "Optionally print the differences as JSON")
+	minUpvotes := fs.Int("min-upvotes", 10, "Minimum upvotes for a new comment to be reported")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: diff [flags] <old export> <new export>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	oldData, err := export.Load(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", fs.Arg(0), err)
+	}
+	newData, err := export.Load(fs.Arg(1))
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", fs.Arg(1), err)
+	}
+
+	result := diff.Compare(oldData, newData, *minUpvotes)
+	if *jsonFlag {
+		if err := result.WriteJSON(os.Stdout); err != nil {
+			log.Fatalf("Failed to print differences: %v", err)
+		}
+		return
+	}
+	result.WriteText(os.Stdout)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"examtopics-downloader/internal/analysis"
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/utils"
+)
+
+func runDisagreements(args []string) {
+	fs := flag.NewFlagSet("disagreements", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	outputPath := fs.String("o", "", "Optional path to write the report to, defaults to printing it")
+	format := fs.String("f", "", "Optional report format (markdown or json), defaults to the extension of -o")
+	topComments := fs.Int("top-comments", analysis.DefaultTopComments, "Number of top upvoted comments whose selected answers are counted")
+	minConfidence := fs.Float64("min-confidence", 0, "Optionally also report questions whose confidence (0-1) is below this")
+	fs.Parse(args)
+
+	report := analysis.Disagreements(questionSource.load(), *topComments, *minConfidence)
+
+	var w io.Writer = os.Stdout
+	if *outputPath != "" {
+		file := utils.CreateFile(*outputPath)
+		defer file.Close()
+		w = file.File
+	}
+
+	var err error
+	switch export.ResolveFormat(*format, *outputPath) {
+	case "json":
+		err = report.WriteJSON(w)
+	case "markdown", "md":
+		err = report.WriteMarkdown(w)
+	default:
+		log.Fatalf("unknown report format %q (expected markdown or json)", *format)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write the report: %v", err)
+	}
+
+	if *outputPath != "" {
+		fmt.Printf("Found %d disagreements, saved to %s.\n", len(report.Disagreements), *outputPath)
+	}
+}
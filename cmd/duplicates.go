@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"examtopics-downloader/internal/dedupe"
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+)
+
+func runDuplicates(args []string) {
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	threshold := fs.Float64("threshold", dedupe.DefaultThreshold, "Similarity (0-1) above which questions count as duplicates")
+	jsonFlag := fs.Bool("json", false, "Optionally print the groups and overlap as JSON")
+	outputPath := fs.String("o", "", "Optionally write the questions with duplicates collapsed to this export")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: duplicates [flags] <export> [export...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var questions []models.QuestionData
+	for _, path := range fs.Args() {
+		loaded, err := export.Load(path)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", path, err)
+		}
+		questions = append(questions, loaded...)
+	}
+
+	groups := dedupe.FindGroups(questions, *threshold)
+	overlaps := dedupe.CrossExamOverlap(questions, groups)
+
+	if *jsonFlag {
+		type jsonGroup struct {
+			Questions []string `json:"questions"`
+		}
+		report := struct {
+			Groups  []jsonGroup      `json:"groups"`
+			Overlap []dedupe.Overlap `json:"overlap"`
+		}{Overlap: overlaps}
+		for _, group := range groups {
+			var links []string
+			for _, i := range group {
+				links = append(links, questions[i].QuestionLink)
+			}
+			report.Groups = append(report.Groups, jsonGroup{Questions: links})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to print duplicates: %v", err)
+		}
+	} else {
+		fmt.Printf("%d groups of near-duplicates among %d questions\n", len(groups), len(questions))
+		for n, group := range groups {
+			fmt.Printf("\nGroup %d:\n", n+1)
+			for _, i := range group {
+				fmt.Printf("  %s\n    %s\n", questions[i].Title, questions[i].QuestionLink)
+			}
+		}
+		if len(overlaps) > 0 {
+			fmt.Println("\nCross-exam overlap:")
+			for _, overlap := range overlaps {
+				fmt.Printf("  %s <-> %s: %d shared questions\n", overlap.ExamA, overlap.ExamB, overlap.Groups)
+			}
+		}
+	}
+
+	if *outputPath != "" {
+		collapsed := dedupe.Collapse(questions, groups)
+		if err := export.Write(collapsed, *outputPath, export.Options{Comments: true}); err != nil {
+			log.Fatalf("Failed to write %s: %v", *outputPath, err)
+		}
+		fmt.Printf("Saved %d questions to %s.\n", len(collapsed), *outputPath)
+	}
+}
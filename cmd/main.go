@@ -1,25 +1,108 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"examtopics-downloader/internal/export"
 	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/manifest"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/update"
 	"examtopics-downloader/internal/utils"
 )
 
+// Subcommands, run as "examtopicsdl <command> [flags]"
+var commands = map[string]func(args []string){
+	"diff":          runDiff,
+	"quiz":          runQuiz,
+	"review":        runReview,
+	"serve":         runServe,
+	"serve-api":     runServeAPI,
+	"search":        runSearch,
+	"duplicates":    runDuplicates,
+	"disagreements": runDisagreements,
+	"stats":         runStats,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	provider := flag.String("p", "google", "Name of the exam provider (default -> google)")
-	grepStr := flag.String("s", "", "String to grep for in discussion links (required)")
+	grepStr := flag.String("s", "", "Exam code or name to search for, resolved against the provider's exams (required)")
 	outputPath := flag.String("o", "examtopics_output.md", "Optional path of the file where the data will be outputted")
 	commentBool := flag.Bool("c", false, "Optionally include all the comment/discussion text")
 	examsFlag := flag.Bool("exams", false, "Optionally show all the possible exams for your selected provider and exit")
 	saveUrls := flag.Bool("save-links", false, "Optional argument to save unique links to questions")
 	noCache := flag.Bool("no-cache", false, "Optional argument, set to disable looking through cached data on github")
 	token := flag.String("t", "", "Optional argument to make cached requests faster to gh api")
+	sources := flag.String("sources", "cache,scrape", "Optional comma separated priority list of data sources (cache, scrape, local, mirror)")
+	localDir := flag.String("local-dir", "", "Optional directory holding a local copy of the cached JSON data, used by the 'local' source")
+	mirrorDir := flag.String("mirror-dir", "", "Optional directory of saved discussion HTML pages, used by the 'mirror' source")
+	selectorsPath := flag.String("selectors", "", "Optional JSON file overriding the CSS selectors used to scrape the site and saved pages")
+	providersFlag := flag.Bool("providers", false, "Optionally list every exam provider and exit")
+	findExam := flag.String("find-exam", "", "Optionally search the exams of every provider by code or name and exit")
+	jsonFlag := flag.Bool("json", false, "Optionally print -providers and -find-exam output as JSON instead of a table")
+	format := flag.String("f", "", "Optional output format (markdown, json, pdf, epub, obsidian or sqlite), defaults to the extension of -o")
+	manifestPath := flag.String("manifest", "", "Optional YAML/JSON/TOML manifest listing several exams to export in one run")
+	updateFlag := flag.Bool("update", false, "Optionally update the existing export at -o, fetching only new or changed questions")
+	collapseDuplicates := flag.Bool("collapse-duplicates", false, "Optionally keep one question per group of near-duplicate questions")
+	answerKey := flag.Bool("answer-key", false, "Optionally add an answer key at the end, PDF and EPUB then leave the answers out of the questions")
+	toc := flag.Bool("toc", false, "Optionally add a table of contents with links to every question to markdown output")
+	tocStyle := flag.String("toc-style", "github", "Optional style of the -toc links, 'github' anchors or 'obsidian' [[#Heading]] links")
+	split := flag.String("split", "", "Optionally split the output by 'topic', by 'page' of cached data, or every N questions, with an index file")
+	templatePath := flag.String("template", "", "Optional text/template or html/template file to render the output with")
+	statsFlag := flag.Bool("stats", false, "Optionally print a statistics summary of the export")
+	statsPath := flag.String("stats-out", "", "Optional path to write the statistics summary to, as JSON when it ends in .json")
+	hybrid := flag.Bool("hybrid", false, "Optional argument to merge the cached data with live scraping of the questions missing from it")
 	flag.Parse()
+	useGitHubToken(*token)
+
+	if *selectorsPath != "" {
+		profile, err := fetch.LoadSelectors(*selectorsPath)
+		if err != nil {
+			log.Fatalf("Failed to load selectors: %v", err)
+		}
+		fetch.SetSelectors(profile)
+	}
+
+	if *providersFlag {
+		providers, err := fetch.ListProviders()
+		if err != nil {
+			log.Fatalf("Failed to list providers: %v", err)
+		}
+		if *jsonFlag {
+			if err := json.NewEncoder(os.Stdout).Encode(providers); err != nil {
+				log.Fatalf("Failed to print providers: %v", err)
+			}
+		} else {
+			for _, name := range providers {
+				fmt.Println(name)
+			}
+		}
+		os.Exit(0)
+	}
+
+	if *findExam != "" {
+		exams, err := fetch.SearchExams(*findExam)
+		if err != nil {
+			log.Fatalf("Failed to search exams: %v", err)
+		}
+		if err := utils.WriteExamList(os.Stdout, exams, *jsonFlag); err != nil {
+			log.Fatalf("Failed to print exams: %v", err)
+		}
+		os.Exit(0)
+	}
 
 	if *examsFlag {
 		exams := fetch.GetProviderExams(*provider)
@@ -30,25 +113,120 @@ func main() {
 		os.Exit(0)
 	}
 
+	outputOpts := export.Options{Format: *format, Comments: *commentBool, Template: *templatePath, AnswerKey: *answerKey, Split: *split, TOC: *toc, TOCStyle: *tocStyle}
+	statsOut := statsOutput{print: *statsFlag, path: *statsPath}
+
+	if *manifestPath != "" {
+		m, err := manifest.Load(*manifestPath)
+		if err != nil {
+			log.Fatalf("Failed to load manifest: %v", err)
+		}
+		if err := manifest.Run(m, manifest.RunOptions{NoCache: *noCache}); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
 	if *grepStr == "" {
 		log.Println("running without a valid string to search for with -s, (no_grep_str)!")
+	} else {
+		*grepStr = resolveExam(*provider, *grepStr)
 	}
 
-	if !*noCache {
-		links := fetch.GetCachedPages(*provider, *grepStr, *token)
-		if len(links) > 0 {
-			utils.WriteData(links, *outputPath, *commentBool)
-			fmt.Printf("Successfully saved cached output to %s.\n", *outputPath)
-			os.Exit(0)
+	sourceNames := *sources
+	if *noCache {
+		sourceNames = strings.ReplaceAll(sourceNames, "cache", "")
+	}
+
+	chain, err := fetch.NewChain(sourceNames, fetch.SourceOptions{
+		LocalDir:  *localDir,
+		MirrorDir: *mirrorDir,
+	})
+	if err != nil {
+		log.Fatalf("invalid -sources: %v", err)
+	}
+
+	if *hybrid {
+		links := fetch.GetHybridPages(chain, *provider, *grepStr)
+		writeOutput(links, *outputPath, outputOpts, *collapseDuplicates, *saveUrls, statsOut)
+		os.Exit(0)
+	}
+
+	if *updateFlag {
+		previous, err := export.Load(*outputPath)
+		if err != nil {
+			log.Fatalf("Failed to read previous export: %v", err)
+		}
+
+		links, report, err := update.Run(chain, *provider, *grepStr, previous)
+		if err != nil {
+			log.Fatalf("Failed to update %s: %v", *outputPath, err)
 		}
+		report.Print(os.Stdout)
+		writeOutput(links, *outputPath, outputOpts, *collapseDuplicates, *saveUrls, statsOut)
+		os.Exit(0)
+	}
+
+	links := chain.FetchAll(*provider, *grepStr)
+	if len(links) == 0 {
+		log.Fatalf("no questions found for provider '%s' using sources '%s'", *provider, chain.Name())
 	}
 
-	fmt.Println("Going to manual scraping, cached data failed.")
-	links := fetch.GetAllPages(*provider, *grepStr)
+	writeOutput(links, *outputPath, outputOpts, *collapseDuplicates, *saveUrls, statsOut)
+}
+
+// Sends every request through a client carrying the GitHub token, set once before any fetch
+func useGitHubToken(token string) {
+	if token != "" {
+		fetch.SetHTTPClient(utils.NewGitHubClient(token))
+	}
+}
 
-	if *saveUrls {
+func writeOutput(links []models.QuestionData, outputPath string, opts export.Options, collapse bool, saveUrls bool, statsOut statsOutput) {
+	if saveUrls {
 		utils.SaveLinks("saved-links.txt", links)
 	}
-	utils.WriteData(links, *outputPath, *commentBool)
-	fmt.Printf("Successfully saved output to %s.\n", *outputPath)
+	output := links
+	if collapse {
+		var groups int
+		output, groups = export.CollapseDuplicates(links)
+		fmt.Printf("Collapsed %d groups of near-duplicate questions\n", groups)
+	}
+	if err := export.Write(output, outputPath, opts); err != nil {
+		log.Fatalf("Failed to write output: %v", err)
+	}
+	fmt.Printf("Successfully saved output to %s.\n", outputPath)
+	statsOut.report(links)
+}
+
+// Resolves -s to a single exam slug, exiting with the candidates when it is ambiguous.
+// An unknown exam is used as given, since the provider page may not list every exam
+func resolveExam(provider, query string) string {
+	slug, err := fetch.ResolveExam(provider, query)
+	var matchErr *fetch.ExamMatchError
+	switch {
+	case err == nil:
+		fmt.Printf("Resolved '%s' to exam '%s'\n", query, slug)
+		return slug
+	case errors.As(err, &matchErr):
+		fmt.Println(err)
+		if len(matchErr.Candidates) > 0 {
+			if matchErr.Ambiguous {
+				fmt.Println("\nMatching exams:")
+			} else {
+				fmt.Println("\nDid you mean:")
+			}
+			for _, candidate := range matchErr.Candidates {
+				fmt.Printf("  %s\n", candidate)
+			}
+		}
+		if matchErr.Ambiguous {
+			os.Exit(1)
+		}
+		fmt.Printf("\nUsing '%s' as the exam code\n", query)
+		return query
+	}
+
+	log.Printf("could not load the exam list to resolve '%s', using it as the exam code: %v", query, err)
+	return query
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+)
+
+// Flags shared by the commands that work on a set of questions
+type questionFlags struct {
+	input    *string
+	provider *string
+	search   *string
+	token    *string
+}
+
+func addQuestionFlags(fs *flag.FlagSet) *questionFlags {
+	return &questionFlags{
+		input:    fs.String("i", "", "Path of a JSON or markdown export to load the questions from"),
+		provider: fs.String("p", "google", "Name of the exam provider, used with -s when no -i is given"),
+		search:   fs.String("s", "", "Exam code or name to load from the cached data when no -i is given"),
+		token:    fs.String("t", "", "Optional argument to make cached requests faster to gh api"),
+	}
+}
+
+// Loads the questions from the export given with -i, or from the cache for -p and -s
+func (f *questionFlags) load() []models.QuestionData {
+	if *f.input != "" {
+		questions, err := export.Load(*f.input)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", *f.input, err)
+		}
+		return questions
+	}
+
+	if *f.search == "" {
+		log.Fatal("either -i or -s is required")
+	}
+
+	useGitHubToken(*f.token)
+	slug := resolveExam(*f.provider, *f.search)
+	questions, err := fetch.GetCachedPages(*f.provider, slug)
+	if err != nil {
+		log.Fatalf("Failed to load the cached questions: %v", err)
+	}
+	if len(questions) == 0 {
+		log.Fatalf("no cached questions found for exam '%s'", slug)
+	}
+	return questions
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"examtopics-downloader/internal/quiz"
+)
+
+func runQuiz(args []string) {
+	fs := flag.NewFlagSet("quiz", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	topic := fs.Int("topic", 0, "Only ask questions from this topic")
+	from := fs.Int("from", 0, "Lowest question number to ask")
+	to := fs.Int("to", 0, "Highest question number to ask")
+	sample := fs.Int("n", 0, "Ask this many randomly picked questions")
+	seed := fs.Int64("seed", 0, "Seed for -n, reuse it to repeat the same mock exam (random when unset)")
+	timeLimit := fs.Duration("time", 0, "Optional time limit such as 90m for a timed mock exam")
+	fs.Parse(args)
+
+	if *sample > 0 && *seed == 0 {
+		*seed = time.Now().UnixNano()
+		fmt.Printf("Sampling with -seed %d\n", *seed)
+	}
+
+	questions := quiz.Select(questionSource.load(), quiz.Filter{
+		Topic:  *topic,
+		From:   *from,
+		To:     *to,
+		Sample: *sample,
+		Seed:   *seed,
+	})
+	if len(questions) == 0 {
+		log.Fatal("no questions match the given filters")
+	}
+
+	session := quiz.NewSession(os.Stdin, os.Stdout)
+	session.Run(questions, *timeLimit).Print(os.Stdout)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"examtopics-downloader/internal/quiz"
+	"examtopics-downloader/internal/review"
+)
+
+func runReview(args []string) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	user := fs.String("user", os.Getenv("USER"), "Name the progress is stored under, so several people can share a machine")
+	progressPath := fs.String("progress", review.DefaultPath(), "Path of the JSON file holding review progress")
+	maxReviews := fs.Int("n", 50, "Maximum number of due questions to review, 0 for all")
+	maxNew := fs.Int("new", 10, "Maximum number of new questions to add to the session")
+	stats := fs.Bool("stats", false, "Show progress per exam and topic and exit")
+	reset := fs.Bool("reset", false, "Reset progress and exit, limited to the exam given with -s when set")
+	fs.Parse(args)
+
+	if *user == "" {
+		*user = "default"
+	}
+
+	store, err := review.Open(*progressPath)
+	if err != nil {
+		log.Fatalf("Failed to read progress from %s: %v", *progressPath, err)
+	}
+
+	if *stats {
+		if err := review.PrintStats(os.Stdout, review.Stats(store, *user, time.Now())); err != nil {
+			log.Fatalf("Failed to print stats: %v", err)
+		}
+		return
+	}
+
+	if *reset {
+		removed := store.Reset(*user, *questionSource.search)
+		if err := store.Save(); err != nil {
+			log.Fatalf("Failed to save progress: %v", err)
+		}
+		fmt.Printf("Reset %d questions for user '%s'.\n", removed, *user)
+		return
+	}
+
+	questions := review.Due(store, *user, questionSource.load(), time.Now(), *maxReviews, *maxNew)
+	if len(questions) == 0 {
+		fmt.Println("Nothing is due for review, come back later.")
+		return
+	}
+
+	fmt.Printf("Reviewing %d questions for user '%s'\n", len(questions), *user)
+	score, err := review.Drill(quiz.NewSession(os.Stdin, os.Stdout), store, *user, questions, os.Stdout)
+	if err != nil {
+		log.Fatalf("Failed to save progress: %v", err)
+	}
+	score.Print(os.Stdout)
+}
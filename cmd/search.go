@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"examtopics-downloader/internal/search"
+)
+
+func runSearch(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	limit := fs.Int("n", 10, "Maximum number of results")
+	noColor := fs.Bool("no-color", false, "Highlight matches with **markdown** instead of terminal colors")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: search [flags] <query>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	query := search.ParseQuery(strings.Join(fs.Args(), " "))
+	if len(query.Terms)+len(query.Phrases) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	highlight := search.TerminalHighlight
+	if *noColor {
+		highlight = search.MarkdownHighlight
+	}
+
+	index := search.NewIndex(questionSource.load())
+	results := index.Search(query, *limit, highlight)
+	if len(results) == 0 {
+		log.Fatal("no questions match the query")
+	}
+
+	for i, result := range results {
+		fmt.Printf("%d. %s (score %.2f, matched in %s)\n", i+1, result.Question.Title, result.Score, result.Field)
+		fmt.Printf("   %s\n", result.Snippet)
+		fmt.Printf("   %s\n\n", result.Question.QuestionLink)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"examtopics-downloader/internal/web"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on, use :8080 to allow other machines")
+	fs.Parse(args)
+
+	title := "Exam Topics Questions"
+	if *questionSource.input != "" {
+		title = filepath.Base(*questionSource.input)
+	} else if *questionSource.search != "" {
+		title = *questionSource.search
+	}
+
+	server, err := web.NewServer(title, questionSource.load())
+	if err != nil {
+		log.Fatalf("Failed to set up the web UI: %v", err)
+	}
+
+	fmt.Printf("Serving on http://%s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.Handler()))
+}
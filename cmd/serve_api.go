@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"examtopics-downloader/internal/api"
+	"examtopics-downloader/internal/fetch"
+)
+
+func runServeAPI(args []string) {
+	fs := flag.NewFlagSet("serve-api", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8081", "Address to listen on")
+	ttl := fs.Duration("ttl", 6*time.Hour, "How long fetched providers, exams and questions are cached")
+	sources := fs.String("sources", "cache,scrape", "Comma separated priority list of data sources (cache, scrape, local, mirror)")
+	localDir := fs.String("local-dir", "", "Directory holding a local copy of the cached JSON data, used by the 'local' source")
+	mirrorDir := fs.String("mirror-dir", "", "Directory of saved discussion HTML pages, used by the 'mirror' source")
+	token := fs.String("t", "", "Optional argument to make cached requests faster to gh api")
+	fs.Parse(args)
+	useGitHubToken(*token)
+
+	chain, err := fetch.NewChain(*sources, fetch.SourceOptions{
+		LocalDir:  *localDir,
+		MirrorDir: *mirrorDir,
+	})
+	if err != nil {
+		log.Fatalf("invalid -sources: %v", err)
+	}
+
+	server := api.NewServer(chain, *ttl)
+	fmt.Printf("Serving the API on http://%s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.Handler()))
+}
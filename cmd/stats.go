@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/stats"
+	"examtopics-downloader/internal/utils"
+)
+
+// Where to show the statistics summary of an export
+type statsOutput struct {
+	print bool
+	path  string
+}
+
+// Prints the summary and writes it to the path, as JSON or text depending on its extension
+func (s statsOutput) report(questions []models.QuestionData) {
+	if !s.print && s.path == "" {
+		return
+	}
+
+	summary := stats.Summarize(questions)
+	if s.print {
+		fmt.Println()
+		summary.WriteText(os.Stdout)
+	}
+	if s.path == "" {
+		return
+	}
+
+	file := utils.CreateFile(s.path)
+	defer file.Close()
+
+	var err error
+	if export.ResolveFormat("", s.path) == "json" {
+		err = summary.WriteJSON(file)
+	} else {
+		err = summary.WriteText(file)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write statistics: %v", err)
+	}
+	fmt.Printf("Saved statistics to %s.\n", s.path)
+}
+
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	questionSource := addQuestionFlags(fs)
+	outputPath := fs.String("o", "", "Optionally write the summary to this file, as JSON when it ends in .json")
+	fs.Parse(args)
+
+	statsOutput{print: true, path: *outputPath}.report(questionSource.load())
+}
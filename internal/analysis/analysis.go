@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Number of top upvoted comments whose selected answers are taken into account
+const DefaultTopComments = 5
+
+// Weight of the neutral prior, so a couple of votes can't make an answer look certain
+const priorWeight = 1.0
+
+// Assessment is how well the community backs a question's suggested answer
+type Assessment struct {
+	Link           string   `json:"link"`
+	Title          string   `json:"title"`
+	Suggested      string   `json:"suggested"`
+	Community      string   `json:"community,omitempty"`
+	CommunityShare float64  `json:"community_share"`
+	Votes          int      `json:"votes"`
+	TopComments    []string `json:"top_comments,omitempty"`
+	Confidence     float64  `json:"confidence"`
+	Contradicted   bool     `json:"contradicted"`
+}
+
+// Scores the suggested answer of a question against its votes and top upvoted comments.
+// The confidence is the share of votes and of top comments (weighted by upvotes) backing
+// the suggested answer, each weighted by how much evidence there is and pulled towards 0.5
+func Assess(question models.QuestionData, topComments int) Assessment {
+	suggested := utils.NormalizeAnswer(question.Answer)
+	assessment := Assessment{
+		Link:      question.QuestionLink,
+		Title:     question.Title,
+		Suggested: suggested,
+	}
+
+	total, backing := 0, 0
+	for _, vote := range question.Votes {
+		total += vote.Count
+		if utils.NormalizeAnswer(vote.Answer) == suggested {
+			backing += vote.Count
+		}
+	}
+	assessment.Votes = total
+	community, share := utils.CommunityAnswer(question.Votes)
+	assessment.Community = utils.NormalizeAnswer(community)
+	assessment.CommunityShare = share
+
+	comments := topSelections(question.Discussion, topComments)
+	commentWeight, commentBacking := 0.0, 0.0
+	commentVotes := make(map[string]float64)
+	for _, comment := range comments {
+		answer := utils.NormalizeAnswer(comment.SelectedAnswer)
+		assessment.TopComments = append(assessment.TopComments, answer)
+		weight := float64(comment.Upvotes + 1)
+		commentWeight += weight
+		commentVotes[answer] += weight
+		if answer == suggested {
+			commentBacking += weight
+		}
+	}
+
+	// Without a vote tally the top comments stand in for the community answer
+	if assessment.Community == "" && commentWeight > 0 {
+		for answer, weight := range commentVotes {
+			if weight > commentVotes[assessment.Community] || (weight == commentVotes[assessment.Community] && answer < assessment.Community) {
+				assessment.Community = answer
+			}
+		}
+		assessment.CommunityShare = commentVotes[assessment.Community] / commentWeight
+	}
+
+	score, weight := 0.5*priorWeight, priorWeight
+	if total > 0 {
+		w := evidence(float64(total))
+		score += w * float64(backing) / float64(total)
+		weight += w
+	}
+	if len(comments) > 0 {
+		w := evidence(float64(len(comments)))
+		score += w * commentBacking / commentWeight
+		weight += w
+	}
+	if suggested == "" {
+		assessment.Confidence = 0
+	} else {
+		assessment.Confidence = score / weight
+	}
+
+	assessment.Contradicted = assessment.Community != "" && assessment.Community != suggested
+	return assessment
+}
+
+// Grows from 0 towards 2 as the number of votes or comments increases
+func evidence(n float64) float64 {
+	return 2 * n / (n + 3)
+}
+
+// Returns up to n comments with a selected answer, most upvoted first
+func topSelections(discussion []models.Comment, n int) []models.Comment {
+	var selected []models.Comment
+	for _, comment := range discussion {
+		if comment.SelectedAnswer != "" {
+			selected = append(selected, comment)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Upvotes > selected[j].Upvotes
+	})
+	if len(selected) > n {
+		selected = selected[:n]
+	}
+	return selected
+}
+
+// Report lists the questions whose suggested answer the community disagrees with
+type Report struct {
+	Total         int          `json:"total"`
+	Disagreements []Assessment `json:"disagreements"`
+}
+
+// Assesses every question and keeps the contradicted ones, plus any scoring below
+// minConfidence, least confident first
+func Disagreements(questions []models.QuestionData, topComments int, minConfidence float64) Report {
+	report := Report{Total: len(questions)}
+	for _, question := range questions {
+		assessment := Assess(question, topComments)
+		if assessment.Contradicted || (assessment.Suggested != "" && assessment.Confidence < minConfidence) {
+			report.Disagreements = append(report.Disagreements, assessment)
+		}
+	}
+	sort.SliceStable(report.Disagreements, func(i, j int) bool {
+		return report.Disagreements[i].Confidence < report.Disagreements[j].Confidence
+	})
+	return report
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r Report) WriteMarkdown(w io.Writer) error {
+	fmt.Fprintf(w, "# Answer disagreements\n\n")
+	fmt.Fprintf(w, "%d of %d questions have a suggested answer the community disagrees with.\n\n", len(r.Disagreements), r.Total)
+	if len(r.Disagreements) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "| Question | Suggested | Community | Votes | Top comments | Confidence |")
+	fmt.Fprintln(w, "| -------- | --------- | --------- | ----- | ------------ | ---------- |")
+	for _, a := range r.Disagreements {
+		community := "-"
+		if a.Community != "" {
+			community = fmt.Sprintf("%s (%.0f%%)", a.Community, a.CommunityShare*100)
+		}
+		_, err := fmt.Fprintf(w, "| [%s](%s) | %s | %s | %d | %s | %.0f%% |\n",
+			escapeCell(a.Title), a.Link, orDash(a.Suggested), community, a.Votes,
+			orDash(strings.Join(a.TopComments, ", ")), a.Confidence*100)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
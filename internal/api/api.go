@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Server exposes the fetch code as a JSON API, caching every result for a while
+type Server struct {
+	chain *fetch.Chain
+	cache *ttlCache
+
+	mu        sync.RWMutex
+	questions map[int]models.QuestionData // by discussion id, filled as exams are loaded
+}
+
+func NewServer(chain *fetch.Chain, ttl time.Duration) *Server {
+	return &Server{
+		chain:     chain,
+		cache:     newTTLCache(ttl),
+		questions: make(map[int]models.QuestionData),
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /providers", s.handleProviders)
+	mux.HandleFunc("GET /providers/{provider}/exams", s.handleExams)
+	mux.HandleFunc("GET /exams/{id}/questions", s.handleExamQuestions)
+	mux.HandleFunc("GET /questions/{id}", s.handleQuestion)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	providers, err := s.cache.get("providers", func() (any, error) {
+		return fetch.ListProviders()
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, providers)
+}
+
+func (s *Server) handleExams(w http.ResponseWriter, r *http.Request) {
+	provider := strings.ToLower(r.PathValue("provider"))
+	exams, err := s.cache.get("exams|"+provider, func() (any, error) {
+		return fetch.ListProviderExamInfo(provider)
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, exams)
+}
+
+// Exam ids are "<provider>:<exam slug>", e.g. "microsoft:az-104"
+func parseExamID(id string) (string, string, error) {
+	provider, slug, ok := strings.Cut(strings.ToLower(id), ":")
+	if !ok || provider == "" || slug == "" {
+		return "", "", fmt.Errorf("invalid exam id %q, expected <provider>:<exam>, e.g. microsoft:az-104", id)
+	}
+	return provider, slug, nil
+}
+
+func (s *Server) handleExamQuestions(w http.ResponseWriter, r *http.Request) {
+	provider, slug, err := parseExamID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	questions, err := s.examQuestions(provider, slug)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, questions)
+}
+
+func (s *Server) examQuestions(provider, slug string) ([]models.QuestionData, error) {
+	value, err := s.cache.get("questions|"+provider+"|"+slug, func() (any, error) {
+		questions := s.chain.FetchAll(provider, slug)
+		if len(questions) == 0 {
+			return nil, fmt.Errorf("no questions found for exam '%s:%s'", provider, slug)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, question := range questions {
+			if ref, ok := utils.ParseQuestionLink(question.QuestionLink); ok {
+				s.questions[ref.ID] = question
+			}
+		}
+		return questions, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.QuestionData), nil
+}
+
+// Questions are looked up by discussion id among the exams loaded so far, or fetched on
+// demand through the chain when the request gives their discussion link, e.g.
+// ?link=https://www.examtopics.com/discussions/microsoft/view/1-exam-az-104-topic-1-question-1-discussion/
+func (s *Server) handleQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("question id must be the numeric discussion id"))
+		return
+	}
+
+	s.mu.RLock()
+	question, ok := s.questions[id]
+	s.mu.RUnlock()
+	if ok {
+		writeJSON(w, http.StatusOK, question)
+		return
+	}
+
+	link := r.URL.Query().Get("link")
+	if link == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("question %d is not loaded, add ?link=<discussion link> to fetch it", id))
+		return
+	}
+	ref, ok := utils.ParseQuestionLink(link)
+	if !ok || ref.ID != id {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%q is not the discussion link of question %d", link, id))
+		return
+	}
+
+	question, err = s.fetchQuestion(link, id)
+	switch {
+	case errors.Is(err, fetch.ErrQuestionNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err)
+	default:
+		writeJSON(w, http.StatusOK, question)
+	}
+}
+
+// Fetches only the linked question, without listing its exam
+func (s *Server) fetchQuestion(link string, id int) (models.QuestionData, error) {
+	value, err := s.cache.get("question|"+strconv.Itoa(id), func() (any, error) {
+		return s.chain.FetchQuestion(link)
+	})
+	if err != nil {
+		return models.QuestionData{}, err
+	}
+	return *value.(*models.QuestionData), nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value   any
+	err     error
+	expires time.Time
+	done    chan struct{}
+}
+
+// ttlCache remembers results for a while and lets concurrent callers share one in-flight load,
+// so several clients asking for the same exam only trigger one crawl
+type ttlCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	return &ttlCache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+func (c *ttlCache) get(key string, load func() (any, error)) (any, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		select {
+		case <-entry.done:
+			if time.Now().Before(entry.expires) && entry.err == nil {
+				c.mu.Unlock()
+				return entry.value, nil
+			}
+		default:
+			c.mu.Unlock()
+			<-entry.done
+			return entry.value, entry.err
+		}
+	}
+
+	c.evictExpired()
+	entry = &cacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.value, entry.err = load()
+	entry.expires = time.Now().Add(c.ttl)
+	close(entry.done)
+	return entry.value, entry.err
+}
+
+// Drops finished entries that have expired, so keys that are never asked for again
+// don't pile up. Called with c.mu held
+func (c *ttlCache) evictExpired() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		select {
+		case <-entry.done:
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
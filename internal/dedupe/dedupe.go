@@ -0,0 +1,265 @@
+package dedupe
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Jaccard similarity above which two questions count as duplicates
+const DefaultThreshold = 0.8
+
+const (
+	shingleSize = 3
+	numHashes   = 128
+	numBands    = 32
+	bandRows    = numHashes / numBands
+)
+
+// Per-hash salts for MinHash, derived once from a fixed seed so signatures are stable across runs
+var salts = func() [numHashes]uint64 {
+	var s [numHashes]uint64
+	x := uint64(0x9E3779B97F4A7C15)
+	for i := range s {
+		// xorshift64*
+		x ^= x >> 12
+		x ^= x << 25
+		x ^= x >> 27
+		s[i] = x * 0x2545F4914F6CDD1D
+	}
+	return s
+}()
+
+// Builds the hashed word shingles of a question's text and choices. Every image link is
+// a shingle of its own, so image-only questions like "HOTSPOT -" differ by their images
+func Shingles(question models.QuestionData) map[uint64]struct{} {
+	var parts []string
+	parts = append(parts, utils.CleanText(utils.QuestionBody(question)))
+	for _, choice := range utils.ParseChoices(question.Questions) {
+		parts = append(parts, choice.Text)
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(strings.Join(parts, " ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	shingles := make(map[uint64]struct{})
+	for _, image := range utils.QuestionImages(question) {
+		shingles[hash("image "+image)] = struct{}{}
+	}
+	if len(words) < shingleSize {
+		if len(words) > 0 {
+			shingles[hash(strings.Join(words, " "))] = struct{}{}
+		}
+		return shingles
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		shingles[hash(strings.Join(words[i:i+shingleSize], " "))] = struct{}{}
+	}
+	return shingles
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func signature(shingles map[uint64]struct{}) [numHashes]uint64 {
+	var sig [numHashes]uint64
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for shingle := range shingles {
+		for i, salt := range salts {
+			if h := mix(shingle ^ salt); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// Jaccard similarity of two shingle sets
+func Similarity(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for shingle := range a {
+		if _, ok := b[shingle]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// Finds groups of near-duplicate questions, returned as indexes into questions.
+// MinHash banding picks candidate pairs, which are then confirmed with their exact
+// Jaccard similarity against threshold
+func FindGroups(questions []models.QuestionData, threshold float64) [][]int {
+	shingles := make([]map[uint64]struct{}, len(questions))
+	buckets := make(map[[2]uint64][]int)
+	for i, question := range questions {
+		shingles[i] = Shingles(question)
+		if len(shingles[i]) == 0 {
+			continue
+		}
+
+		sig := signature(shingles[i])
+		for band := range numBands {
+			h := fnv.New64a()
+			for _, value := range sig[band*bandRows : (band+1)*bandRows] {
+				for shift := 0; shift < 64; shift += 8 {
+					h.Write([]byte{byte(value >> shift)})
+				}
+			}
+			key := [2]uint64{uint64(band), h.Sum64()}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	parent := make([]int, len(questions))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	checked := make(map[[2]int]bool)
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if checked[pair] {
+					continue
+				}
+				checked[pair] = true
+				if Similarity(shingles[pair[0]], shingles[pair[1]]) >= threshold {
+					parent[find(pair[1])] = find(pair[0])
+				}
+			}
+		}
+	}
+
+	byRoot := make(map[int][]int)
+	for i := range questions {
+		byRoot[find(i)] = append(byRoot[find(i)], i)
+	}
+
+	var groups [][]int
+	for _, members := range byRoot {
+		if len(members) > 1 {
+			sort.Ints(members)
+			groups = append(groups, members)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
+
+// Records in every question of a group the links of the others
+func Annotate(questions []models.QuestionData, groups [][]int) []models.QuestionData {
+	annotated := make([]models.QuestionData, len(questions))
+	copy(annotated, questions)
+
+	for _, group := range groups {
+		for _, i := range group {
+			annotated[i].Duplicates = nil
+			for _, j := range group {
+				if i != j {
+					annotated[i].Duplicates = append(annotated[i].Duplicates, questions[j].QuestionLink)
+				}
+			}
+		}
+	}
+	return annotated
+}
+
+// Keeps the first question of every group, noting the links of the dropped ones in its Duplicates
+func Collapse(questions []models.QuestionData, groups [][]int) []models.QuestionData {
+	dropped := make(map[int]bool)
+	annotated := Annotate(questions, groups)
+	for _, group := range groups {
+		for _, i := range group[1:] {
+			dropped[i] = true
+		}
+	}
+
+	var collapsed []models.QuestionData
+	for i, question := range annotated {
+		if !dropped[i] {
+			collapsed = append(collapsed, question)
+		}
+	}
+	return collapsed
+}
+
+// Overlap counts the duplicate groups shared by two exams
+type Overlap struct {
+	ExamA  string `json:"exam_a"`
+	ExamB  string `json:"exam_b"`
+	Groups int    `json:"groups"`
+}
+
+// Reports, for every pair of exams, how many duplicate groups span both
+func CrossExamOverlap(questions []models.QuestionData, groups [][]int) []Overlap {
+	counts := make(map[[2]string]int)
+	for _, group := range groups {
+		exams := make(map[string]bool)
+		for _, i := range group {
+			exams[examOf(questions[i])] = true
+		}
+
+		var names []string
+		for exam := range exams {
+			names = append(names, exam)
+		}
+		sort.Strings(names)
+		for x := 0; x < len(names); x++ {
+			for y := x + 1; y < len(names); y++ {
+				counts[[2]string{names[x], names[y]}]++
+			}
+		}
+	}
+
+	var overlaps []Overlap
+	for pair, count := range counts {
+		overlaps = append(overlaps, Overlap{ExamA: pair[0], ExamB: pair[1], Groups: count})
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].Groups != overlaps[j].Groups {
+			return overlaps[i].Groups > overlaps[j].Groups
+		}
+		return overlaps[i].ExamA+overlaps[i].ExamB < overlaps[j].ExamA+overlaps[j].ExamB
+	})
+	return overlaps
+}
+
+func examOf(question models.QuestionData) string {
+	if ref, ok := utils.ParseQuestionLink(question.QuestionLink); ok {
+		return ref.Exam
+	}
+	return "unknown"
+}
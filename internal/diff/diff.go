@@ -0,0 +1,181 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+type Question struct {
+	Link  string `json:"link"`
+	Title string `json:"title"`
+}
+
+type AnswerChange struct {
+	Question
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+type VoteShift struct {
+	Question
+	OldMajority string  `json:"old_majority"`
+	OldShare    float64 `json:"old_share"`
+	NewMajority string  `json:"new_majority"`
+	NewShare    float64 `json:"new_share"`
+}
+
+type NewComment struct {
+	Question
+	Comment models.Comment `json:"comment"`
+}
+
+// Result holds every difference found between two exports of the same exam
+type Result struct {
+	Added         []Question     `json:"added"`
+	Removed       []Question     `json:"removed"`
+	AnswerChanges []AnswerChange `json:"answer_changes"`
+	VoteShifts    []VoteShift    `json:"vote_shifts"`
+	NewComments   []NewComment   `json:"new_comments"`
+}
+
+func (r Result) Empty() bool {
+	return len(r.Added)+len(r.Removed)+len(r.AnswerChanges)+len(r.VoteShifts)+len(r.NewComments) == 0
+}
+
+// Compares two exports by question link. Only new comments with at least minUpvotes upvotes are reported
+func Compare(oldData, newData []models.QuestionData, minUpvotes int) Result {
+	var result Result
+
+	oldByLink := make(map[string]models.QuestionData, len(oldData))
+	for _, data := range oldData {
+		oldByLink[utils.NormalizeQuestionURL(data.QuestionLink)] = data
+	}
+	newByLink := make(map[string]struct{}, len(newData))
+
+	for _, fresh := range newData {
+		key := utils.NormalizeQuestionURL(fresh.QuestionLink)
+		newByLink[key] = struct{}{}
+		question := Question{Link: fresh.QuestionLink, Title: fresh.Title}
+
+		old, ok := oldByLink[key]
+		if !ok {
+			result.Added = append(result.Added, question)
+			continue
+		}
+
+		if old.Answer != fresh.Answer {
+			result.AnswerChanges = append(result.AnswerChanges, AnswerChange{Question: question, Old: old.Answer, New: fresh.Answer})
+		}
+
+		oldMajority, oldShare := utils.CommunityAnswer(old.Votes)
+		newMajority, newShare := utils.CommunityAnswer(fresh.Votes)
+		if oldMajority != newMajority && newMajority != "" {
+			result.VoteShifts = append(result.VoteShifts, VoteShift{
+				Question:    question,
+				OldMajority: oldMajority,
+				OldShare:    oldShare,
+				NewMajority: newMajority,
+				NewShare:    newShare,
+			})
+		}
+
+		seen := make(map[string]struct{}, len(old.Discussion))
+		for _, comment := range old.Discussion {
+			seen[commentKey(comment)] = struct{}{}
+		}
+		for _, comment := range fresh.Discussion {
+			if _, exists := seen[commentKey(comment)]; !exists && comment.Upvotes >= minUpvotes {
+				result.NewComments = append(result.NewComments, NewComment{Question: question, Comment: comment})
+			}
+		}
+	}
+
+	for _, old := range oldData {
+		if _, ok := newByLink[utils.NormalizeQuestionURL(old.QuestionLink)]; !ok {
+			result.Removed = append(result.Removed, Question{Link: old.QuestionLink, Title: old.Title})
+		}
+	}
+
+	sort.SliceStable(result.NewComments, func(i, j int) bool {
+		return result.NewComments[i].Comment.Upvotes > result.NewComments[j].Comment.Upvotes
+	})
+	return result
+}
+
+// Upvotes and timestamps change over time, so comments are matched by author and text
+func commentKey(comment models.Comment) string {
+	return comment.Poster + "\x00" + utils.CleanText(comment.Content)
+}
+
+func (r Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r Result) WriteText(w io.Writer) {
+	if r.Empty() {
+		fmt.Fprintln(w, "No differences found.")
+		return
+	}
+
+	fmt.Fprintf(w, "%d new, %d removed, %d changed answers, %d vote shifts, %d new upvoted comments\n",
+		len(r.Added), len(r.Removed), len(r.AnswerChanges), len(r.VoteShifts), len(r.NewComments))
+
+	section := func(title string, count int) bool {
+		if count == 0 {
+			return false
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		return true
+	}
+
+	if section("New questions", len(r.Added)) {
+		for _, q := range r.Added {
+			fmt.Fprintf(w, "  + %s\n    %s\n", q.Title, q.Link)
+		}
+	}
+	if section("Removed questions", len(r.Removed)) {
+		for _, q := range r.Removed {
+			fmt.Fprintf(w, "  - %s\n    %s\n", q.Title, q.Link)
+		}
+	}
+	if section("Changed suggested answers", len(r.AnswerChanges)) {
+		for _, c := range r.AnswerChanges {
+			fmt.Fprintf(w, "  %s: %s -> %s\n    %s\n", c.Title, c.Old, c.New, c.Link)
+		}
+	}
+	if section("Shifted vote majorities", len(r.VoteShifts)) {
+		for _, s := range r.VoteShifts {
+			fmt.Fprintf(w, "  %s: %s (%.0f%%) -> %s (%.0f%%)\n    %s\n",
+				s.Title, orNone(s.OldMajority), s.OldShare*100, s.NewMajority, s.NewShare*100, s.Link)
+		}
+	}
+	if section("New highly upvoted comments", len(r.NewComments)) {
+		for _, c := range r.NewComments {
+			fmt.Fprintf(w, "  %s [%d upvotes] %s: %s\n    %s\n",
+				c.Title, c.Comment.Upvotes, c.Comment.Poster, truncate(c.Comment.Content, 200), c.Link)
+		}
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "..."
+}
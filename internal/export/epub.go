@@ -0,0 +1,221 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"image"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+//go:embed templates/epub/*
+var epubFiles embed.FS
+
+const epubMaxComments = 5
+
+type epubBook struct {
+	Identifier string
+	Title      string
+	Modified   string
+	Chapters   []*epubChapter
+	Images     []epubImage
+}
+
+// epubChapter holds the questions of one topic
+type epubChapter struct {
+	ID        string
+	File      string
+	Title     string
+	Endnotes  bool
+	Questions []epubQuestion
+}
+
+type epubQuestion struct {
+	TemplateQuestion
+	Images  []epubImageRef
+	Endnote bool
+}
+
+// epubImageRef is an image embedded in the book, or just its link when it couldn't be loaded
+type epubImageRef struct {
+	File string
+	Link string
+}
+
+type epubImage struct {
+	ID        string
+	File      string
+	MediaType string
+	data      []byte
+}
+
+// Writes an EPUB 3 book with a chapter per topic. Answers are footnotes after each
+// question, or end-of-chapter notes when opts.AnswerKey is set
+func writeEPUB(dataList []models.QuestionData, outputPath string, opts Options) error {
+	templates, err := template.New("").Funcs(template.FuncMap{
+		"inc":     func(i int) int { return i + 1 },
+		"label":   func(q epubQuestion) string { return questionLabel(q.TemplateQuestion) },
+		"lines":   func(s string) []string { return strings.Split(strings.TrimSpace(s), "\n") },
+		"percent": func(share float64) string { return fmt.Sprintf("%.0f%%", share*100) },
+		"votes":   utils.FormatVotes,
+	}).ParseFS(epubFiles, "templates/epub/*")
+	if err != nil {
+		return err
+	}
+
+	book := newEPUBBook(NewTemplateData(dataList, opts.Comments), opts.AnswerKey)
+
+	file := utils.CreateFile(outputPath)
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	modified := time.Now()
+	create := func(name string, method uint16) (io.Writer, error) {
+		return archive.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+	}
+
+	// The mimetype must come first and be stored uncompressed
+	mimetype, err := create("mimetype", zip.Store)
+	if err != nil {
+		return err
+	}
+	io.WriteString(mimetype, "application/epub+zip")
+
+	entries := []struct {
+		name     string
+		template string
+		data     any
+	}{
+		{"META-INF/container.xml", "container.xml", book},
+		{"OEBPS/content.opf", "content.opf", book},
+		{"OEBPS/toc.ncx", "toc.ncx", book},
+		{"OEBPS/nav.xhtml", "nav.xhtml", book},
+		{"OEBPS/style.css", "style.css", book},
+	}
+	for _, chapter := range book.Chapters {
+		entries = append(entries, struct {
+			name     string
+			template string
+			data     any
+		}{"OEBPS/" + chapter.File, "chapter.xhtml", chapter})
+	}
+
+	for _, entry := range entries {
+		w, err := create(entry.name, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		// html/template escapes the XML declaration, so it is written here instead
+		if entry.template != "style.css" {
+			io.WriteString(w, xml.Header)
+		}
+		if err := templates.ExecuteTemplate(w, entry.template, entry.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", entry.name, err)
+		}
+	}
+
+	for _, img := range book.Images {
+		w, err := create("OEBPS/"+img.File, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(img.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// Groups the questions into chapters by topic and loads their images
+func newEPUBBook(data TemplateData, endnotes bool) *epubBook {
+	title := strings.ToUpper(data.Exam)
+	if title == "" {
+		title = "Exam Topics Questions"
+	}
+
+	// A stable identifier, so re-exports of the same questions replace each other on a reader
+	hash := sha1.New()
+	for _, question := range data.Questions {
+		io.WriteString(hash, question.Link)
+	}
+	sum := hash.Sum(nil)
+
+	book := &epubBook{
+		Identifier: fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]),
+		Title:      title,
+		Modified:   data.Generated.UTC().Format(time.RFC3339),
+	}
+
+	byTopic := make(map[int]*epubChapter)
+	loaded := make(map[string]string)
+	for _, question := range data.Questions {
+		chapter, ok := byTopic[question.Topic]
+		if !ok {
+			chapter = &epubChapter{Title: "Questions", Endnotes: endnotes}
+			if question.Topic > 0 {
+				chapter.Title = fmt.Sprintf("Topic %d", question.Topic)
+			}
+			byTopic[question.Topic] = chapter
+			book.Chapters = append(book.Chapters, chapter)
+		}
+
+		item := epubQuestion{TemplateQuestion: question, Endnote: endnotes}
+		item.Comments = topComments(question.Comments, epubMaxComments)
+		for _, link := range question.Images {
+			file, ok := loaded[link]
+			if !ok {
+				file = book.addImage(link)
+				loaded[link] = file
+			}
+			item.Images = append(item.Images, epubImageRef{File: file, Link: link})
+		}
+		chapter.Questions = append(chapter.Questions, item)
+	}
+
+	sort.SliceStable(book.Chapters, func(i, j int) bool {
+		return chapterTopic(book.Chapters[i]) < chapterTopic(book.Chapters[j])
+	})
+	for i, chapter := range book.Chapters {
+		chapter.ID = fmt.Sprintf("chapter-%d", i+1)
+		chapter.File = chapter.ID + ".xhtml"
+	}
+	return book
+}
+
+func chapterTopic(chapter *epubChapter) int {
+	return chapter.Questions[0].Topic
+}
+
+// Loads an image into the book, returning its file name or "" when it can't be used
+func (b *epubBook) addImage(link string) string {
+	raw, err := loadImage(link)
+	if err != nil {
+		log.Printf("skipping image %s: %v", link, err)
+		return ""
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		log.Printf("skipping image %s: %v", link, err)
+		return ""
+	}
+
+	id := fmt.Sprintf("image-%d", len(b.Images)+1)
+	file := path.Join("images", id+"."+format)
+	b.Images = append(b.Images, epubImage{ID: id, File: file, MediaType: "image/" + format, data: raw})
+	return file
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"examtopics-downloader/internal/dedupe"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+type Options struct {
+	Format   string
+	Comments bool
+	// Optional text/template or html/template file rendering the output instead of Format
+	Template string
+	// Add an answer key at the end. PDF and EPUB move the answers there instead of under each question
+	AnswerKey bool
+	// Add a table of contents with links to every question to markdown output
+	TOC bool
+	// Style of the table of contents links, "github" (default) or "obsidian"
+	TOCStyle string
+	// Split the output into one file per topic, per page of cached data or per N questions
+	Split string
+}
+
+// Picks the output format from the explicit option, falling back to the file extension
+func ResolveFormat(format, path string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".pdf":
+		return "pdf"
+	case ".epub":
+		return "epub"
+	case ".db", ".sqlite", ".sqlite3":
+		return "sqlite"
+	}
+	return "markdown"
+}
+
+// Keeps one question per group of near-duplicates, listing the others under it, and
+// returns how many groups were collapsed
+func CollapseDuplicates(dataList []models.QuestionData) ([]models.QuestionData, int) {
+	groups := dedupe.FindGroups(dataList, dedupe.DefaultThreshold)
+	return dedupe.Collapse(dataList, groups), len(groups)
+}
+
+// Writes the questions to path in the requested format
+func Write(dataList []models.QuestionData, path string, opts Options) error {
+	switch opts.TOCStyle {
+	case "", utils.GitHubLinks, utils.ObsidianLinks:
+	default:
+		return fmt.Errorf("invalid toc style %q (expected github or obsidian)", opts.TOCStyle)
+	}
+
+	if opts.Split != "" {
+		return writeSplit(dataList, path, opts)
+	}
+
+	if opts.Template != "" {
+		return writeTemplate(dataList, path, opts.Template, opts.Comments)
+	}
+
+	switch ResolveFormat(opts.Format, path) {
+	case "markdown", "md":
+		utils.WriteMarkdown(dataList, path, utils.MarkdownOptions{
+			Comments:  opts.Comments,
+			TOC:       opts.TOC,
+			AnswerKey: opts.AnswerKey,
+			LinkStyle: opts.TOCStyle,
+		})
+		return nil
+	case "json":
+		return writeJSON(dataList, path, opts.Comments)
+	case "pdf":
+		return writePDF(dataList, path, opts)
+	case "epub":
+		return writeEPUB(dataList, path, opts)
+	case "obsidian", "vault":
+		return writeVault(dataList, path, opts)
+	case "sqlite":
+		return writeSQLite(dataList, path, opts)
+	}
+	return fmt.Errorf("unknown output format %q (expected markdown, json, pdf, epub, obsidian or sqlite)", opts.Format)
+}
+
+func writeJSON(dataList []models.QuestionData, path string, commentBool bool) error {
+	file := utils.CreateFile(path)
+	defer file.Close()
+
+	if !commentBool {
+		stripped := make([]models.QuestionData, len(dataList))
+		copy(stripped, dataList)
+		for i := range stripped {
+			stripped[i].Comments = ""
+			stripped[i].Discussion = nil
+		}
+		dataList = stripped
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dataList)
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+var (
+	answerLineRe    = regexp.MustCompile(`^\*\*Answer: (.*)\*\*$`)
+	votesLineRe     = regexp.MustCompile(`^\*\*Community vote: (.*)\*\*$`)
+	timestampLineRe = regexp.MustCompile(`^\*\*Timestamp: (.*)\*\*$`)
+	linkLineRe      = regexp.MustCompile(`^\[View on ExamTopics\]\((.*)\)$`)
+	choiceLineRe    = regexp.MustCompile(`^(\*\*[A-Z]:\*\*|[A-Z]\.) `)
+)
+
+// Loads a previous JSON or markdown export, picked by file extension
+func Load(path string) ([]models.QuestionData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ResolveFormat("", path) == "json" {
+		var dataList []models.QuestionData
+		if err := json.Unmarshal(data, &dataList); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON export %s: %w", path, err)
+		}
+		return dataList, nil
+	}
+	return parseMarkdown(string(data)), nil
+}
+
+// Parses the layout written by utils.WriteData. Question text and images end up in
+// Header, since the markdown does not keep them apart
+func parseMarkdown(content string) []models.QuestionData {
+	var dataList []models.QuestionData
+	var current *models.QuestionData
+	var paragraphs []string
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if current.Title == utils.TOCHeading || current.Title == utils.AnswerKeyHeading {
+			current = nil
+			paragraphs = nil
+			return
+		}
+		var header []string
+		for _, paragraph := range paragraphs {
+			if choiceLineRe.MatchString(paragraph) {
+				current.Questions = append(current.Questions, paragraph)
+			} else if len(current.Questions) == 0 {
+				header = append(header, paragraph)
+			}
+		}
+		current.Header = strings.Join(header, "\n\n")
+		dataList = append(dataList, *current)
+		current = nil
+		paragraphs = nil
+	}
+
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 && current != nil {
+			paragraphs = append(paragraphs, strings.Join(paragraph, "\n"))
+		}
+		paragraph = nil
+	}
+
+	// Fence of the code block being read, whose lines are kept even when they look like markup
+	var fence string
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if current != nil && current.Answer == "" {
+			if fence != "" {
+				paragraph = append(paragraph, line)
+				if strings.TrimSpace(line) == fence {
+					fence = ""
+				}
+				continue
+			}
+			if strings.HasPrefix(line, "```") {
+				fence = line[:len(line)-len(strings.TrimLeft(line, "`"))]
+				paragraph = append(paragraph, line)
+				continue
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "## "):
+			endParagraph()
+			flush()
+			current = &models.QuestionData{Title: strings.TrimPrefix(line, "## ")}
+		case current == nil:
+		case answerLineRe.MatchString(line):
+			endParagraph()
+			current.Answer = answerLineRe.FindStringSubmatch(line)[1]
+		case votesLineRe.MatchString(line):
+			current.Votes = utils.ParseVotes(votesLineRe.FindStringSubmatch(line)[1])
+		case timestampLineRe.MatchString(line):
+			current.Timestamp = timestampLineRe.FindStringSubmatch(line)[1]
+		case linkLineRe.MatchString(line):
+			current.QuestionLink = linkLineRe.FindStringSubmatch(line)[1]
+		case strings.HasPrefix(line, "Also asked as: "):
+			current.Duplicates = strings.Fields(strings.TrimPrefix(line, "Also asked as: "))
+		case strings.HasPrefix(line, "Comments: "):
+			current.Comments = strings.TrimPrefix(line, "Comments: ")
+		case strings.HasPrefix(line, "----------------------------------------"):
+			endParagraph()
+			flush()
+		case strings.TrimSpace(line) == "":
+			endParagraph()
+		case current.Answer == "":
+			paragraph = append(paragraph, line)
+		}
+	}
+	endParagraph()
+	flush()
+
+	return dataList
+}
@@ -0,0 +1,256 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"examtopics-downloader/internal/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfLineHeight   = 5.5
+	pdfMaxComments  = 3
+	pdfImageTimeout = 30 * time.Second
+)
+
+var imageClient = &http.Client{Timeout: pdfImageTimeout}
+
+// Lays the questions out as a printable A4 mock exam. Answers go inline, or into an
+// answer key appendix when opts.AnswerKey is set
+func writePDF(dataList []models.QuestionData, path string, opts Options) error {
+	data := NewTemplateData(dataList, opts.Comments)
+	exam := strings.ToUpper(data.Exam)
+	if exam == "" {
+		exam = "Exam Topics Questions"
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(exam, true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	contentWidth := pageWidth - left - right
+
+	// The header names the exam and the question the page starts with
+	current := ""
+	if len(data.Questions) > 0 {
+		current = questionLabel(data.Questions[0])
+	}
+	pdf.SetHeaderFuncMode(func() {
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(contentWidth/2, 6, tr(exam), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth/2, 6, tr(current), "B", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+	}, false)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(0, 6, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.MultiCell(0, 9, tr(exam), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(0, pdfLineHeight, fmt.Sprintf("%d questions", len(data.Questions)), "", "L", false)
+	pdf.Ln(6)
+
+	images := make(map[string]*fpdf.ImageInfoType)
+	for _, question := range data.Questions {
+		current = questionLabel(question)
+
+		// Keep the heading with at least a few lines of the question
+		if pdf.GetY() > pageHeight-bottom-30 {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.MultiCell(0, 7, tr(questionLabel(question)), "", "L", false)
+		pdf.Ln(1)
+
+		pdf.SetFont("Helvetica", "", 10)
+		if text := strings.TrimSpace(question.Text); text != "" {
+			pdf.MultiCell(0, pdfLineHeight, tr(text), "", "L", false)
+			pdf.Ln(2)
+		}
+
+		for _, link := range question.Images {
+			info := pdfImage(pdf, images, link)
+			if info == nil {
+				pdf.SetTextColor(0, 0, 200)
+				pdf.MultiCell(0, pdfLineHeight, tr(link), "", "L", false)
+				pdf.SetTextColor(0, 0, 0)
+				continue
+			}
+
+			width, height := info.Extent()
+			if width > contentWidth {
+				width, height = contentWidth, height*contentWidth/width
+			}
+			if maxHeight := pageHeight - 50; height > maxHeight {
+				width, height = width*maxHeight/height, maxHeight
+			}
+			if pdf.GetY()+height > pageHeight-bottom {
+				pdf.AddPage()
+			}
+			pdf.ImageOptions(link, left, pdf.GetY(), width, height, true, fpdf.ImageOptions{}, 0, "")
+			pdf.Ln(2)
+		}
+
+		for _, choice := range question.Choices {
+			pdf.SetX(left + 4)
+			pdf.MultiCell(contentWidth-4, pdfLineHeight, tr(choice.Letter+". "+choice.Text), "", "L", false)
+		}
+		if len(question.Choices) == 0 && len(question.Data.Questions) > 0 {
+			pdf.MultiCell(0, pdfLineHeight, tr(strings.Join(question.Data.Questions, "\n")), "", "L", false)
+		}
+		pdf.Ln(2)
+
+		if !opts.AnswerKey {
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.MultiCell(0, pdfLineHeight, tr(answerLine(question)), "", "L", false)
+			pdf.SetFont("Helvetica", "", 10)
+		}
+
+		if opts.Comments && len(question.Comments) > 0 {
+			pdf.Ln(1)
+			pdf.SetFont("Helvetica", "", 8)
+			for _, comment := range topComments(question.Comments, pdfMaxComments) {
+				pdf.SetX(left + 4)
+				line := fmt.Sprintf("%s (%d upvotes): %s", comment.Poster, comment.Upvotes, comment.Content)
+				pdf.MultiCell(contentWidth-4, 4, tr(line), "", "L", false)
+				pdf.Ln(1)
+			}
+			pdf.SetFont("Helvetica", "", 10)
+		}
+
+		pdf.SetTextColor(0, 0, 200)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, pdfLineHeight, tr(question.Link), "", 1, "L", false, 0, question.Link)
+		pdf.SetTextColor(0, 0, 0)
+
+		pdf.Ln(3)
+		pdf.Line(left, pdf.GetY(), pageWidth-right, pdf.GetY())
+		pdf.Ln(5)
+	}
+
+	if opts.AnswerKey && len(data.Questions) > 0 {
+		current = "Answer key"
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.MultiCell(0, 8, "Answer key", "", "L", false)
+		pdf.Ln(2)
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(contentWidth*0.5, 6, "Question", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth*0.2, 6, "Answer", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth*0.3, 6, "Community", "B", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, question := range data.Questions {
+			community := ""
+			if question.CommunityAnswer != "" {
+				community = fmt.Sprintf("%s (%.0f%%)", question.CommunityAnswer, question.CommunityShare*100)
+			}
+			pdf.CellFormat(contentWidth*0.5, 6, tr(questionLabel(question)), "", 0, "L", false, 0, "")
+			pdf.CellFormat(contentWidth*0.2, 6, tr(question.Answer), "", 0, "L", false, 0, "")
+			pdf.CellFormat(contentWidth*0.3, 6, tr(community), "", 1, "L", false, 0, "")
+		}
+	}
+
+	return pdf.OutputFileAndClose(path)
+}
+
+// Names a question by its topic and number when the link has them
+func questionLabel(question TemplateQuestion) string {
+	if question.Topic > 0 {
+		return fmt.Sprintf("Question %d (topic %d, #%d)", question.Number, question.Topic, question.QuestionNumber)
+	}
+	return fmt.Sprintf("Question %d", question.Number)
+}
+
+func answerLine(question TemplateQuestion) string {
+	line := "Answer: " + question.Answer
+	if question.CommunityAnswer != "" {
+		line += fmt.Sprintf("   Community: %s (%.0f%%)", question.CommunityAnswer, question.CommunityShare*100)
+	}
+	return line
+}
+
+// Returns up to n comments, most upvoted first
+func topComments(comments []models.Comment, n int) []models.Comment {
+	sorted := append([]models.Comment(nil), comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Upvotes > sorted[j].Upvotes
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// Registers the image behind a link once, returning nil when it can't be loaded or decoded
+func pdfImage(pdf *fpdf.Fpdf, images map[string]*fpdf.ImageInfoType, link string) *fpdf.ImageInfoType {
+	if info, ok := images[link]; ok {
+		return info
+	}
+
+	images[link] = nil
+	raw, err := loadImage(link)
+	if err != nil {
+		log.Printf("skipping image %s: %v", link, err)
+		return nil
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		log.Printf("skipping image %s: %v", link, err)
+		return nil
+	}
+
+	info := pdf.RegisterImageOptionsReader(link, fpdf.ImageOptions{ImageType: format}, bytes.NewReader(raw))
+	if pdf.Err() {
+		log.Printf("skipping image %s: %v", link, pdf.Error())
+		pdf.ClearError()
+		return nil
+	}
+	images[link] = info
+	return info
+}
+
+// Reads an image from a URL or a local path
+func loadImage(link string) ([]byte, error) {
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return os.ReadFile(link)
+	}
+
+	resp, err := imageClient.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Part is one file of a split export
+type Part struct {
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	Questions int    `json:"questions"`
+
+	key       int
+	questions []models.QuestionData
+}
+
+// Groups the questions by topic, by page of the cached data, or into parts of N questions
+func SplitQuestions(dataList []models.QuestionData, mode string) ([]Part, error) {
+	var keyOf func(i int, question models.QuestionData) int
+	var nameOf func(key int) (string, string)
+
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case "topic":
+		keyOf = func(_ int, question models.QuestionData) int {
+			ref, _ := utils.ParseQuestionLink(question.QuestionLink)
+			return ref.Topic
+		}
+		nameOf = func(key int) (string, string) {
+			if key == 0 {
+				return "Other questions", "other"
+			}
+			return fmt.Sprintf("Topic %d", key), fmt.Sprintf("topic-%d", key)
+		}
+	case "page":
+		keyOf = func(_ int, question models.QuestionData) int {
+			return question.Page
+		}
+		nameOf = func(key int) (string, string) {
+			if key == 0 {
+				return "Other questions", "other"
+			}
+			return fmt.Sprintf("Page %d", key), fmt.Sprintf("page-%d", key)
+		}
+	default:
+		size, err := strconv.Atoi(mode)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid split %q (expected topic, page or a number of questions)", mode)
+		}
+		keyOf = func(i int, _ models.QuestionData) int {
+			return i/size + 1
+		}
+		nameOf = func(key int) (string, string) {
+			first := (key-1)*size + 1
+			last := min(key*size, len(dataList))
+			return fmt.Sprintf("Questions %d-%d", first, last), fmt.Sprintf("part-%d", key)
+		}
+	}
+
+	byKey := make(map[int]*Part)
+	var parts []*Part
+	for i, question := range dataList {
+		key := keyOf(i, question)
+		part, ok := byKey[key]
+		if !ok {
+			part = &Part{key: key}
+			byKey[key] = part
+			parts = append(parts, part)
+		}
+		part.questions = append(part.questions, question)
+	}
+
+	// Questions without a topic or page go last
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := parts[i].key, parts[j].key
+		if a == 0 || b == 0 {
+			return b == 0 && a != 0
+		}
+		return a < b
+	})
+
+	result := make([]Part, 0, len(parts))
+	for _, part := range parts {
+		part.Name, part.File = nameOf(part.key)
+		part.Questions = len(part.questions)
+		result = append(result, *part)
+	}
+	return result, nil
+}
+
+// Writes every part next to path, named <name>-<part><ext>, followed by an index linking them
+func writeSplit(dataList []models.QuestionData, path string, opts Options) error {
+	parts, err := SplitQuestions(dataList, opts.Split)
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	partOpts := opts
+	partOpts.Split = ""
+	if partOpts.Format == "" && partOpts.Template == "" {
+		partOpts.Format = ResolveFormat("", path)
+	}
+
+	for i := range parts {
+		partPath := base + "-" + parts[i].File + ext
+		parts[i].File = filepath.Base(partPath)
+		if err := Write(parts[i].questions, partPath, partOpts); err != nil {
+			return fmt.Errorf("failed to write %s: %w", partPath, err)
+		}
+	}
+
+	if partOpts.Template == "" && partOpts.Format == "json" {
+		return writeJSONIndex(parts, base+"-index.json")
+	}
+	return writeMarkdownIndex(dataList, parts, base+"-index.md")
+}
+
+func writeJSONIndex(parts []Part, path string) error {
+	file := utils.CreateFile(path)
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(parts)
+}
+
+func writeMarkdownIndex(dataList []models.QuestionData, parts []Part, path string) error {
+	file := utils.CreateFile(path)
+	defer file.Close()
+
+	title := "Exam Topics Questions"
+	if exam := NewTemplateData(dataList, false).Exam; exam != "" {
+		title = strings.ToUpper(exam) + " questions"
+	}
+
+	fmt.Fprintf(file, "# %s\n\n", title)
+	fmt.Fprintf(file, "%d questions in %d parts.\n\n", len(dataList), len(parts))
+	fmt.Fprintln(file, "| Part | Questions |")
+	fmt.Fprintln(file, "| ---- | --------- |")
+	for _, part := range parts {
+		if _, err := fmt.Fprintf(file, "| [%s](%s) | %d |\n", part.Name, strings.ReplaceAll(part.File, " ", "%20"), part.Questions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
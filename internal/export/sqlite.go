@@ -0,0 +1,258 @@
+package export
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS exports (
+	id          INTEGER PRIMARY KEY,
+	exported_at TEXT NOT NULL,
+	questions   INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS exams (
+	id       INTEGER PRIMARY KEY,
+	provider TEXT NOT NULL,
+	slug     TEXT NOT NULL,
+	UNIQUE (provider, slug)
+);
+
+CREATE TABLE IF NOT EXISTS questions (
+	id                INTEGER PRIMARY KEY,
+	url               TEXT NOT NULL UNIQUE,
+	exam_id           INTEGER REFERENCES exams (id),
+	topic             INTEGER,
+	number            INTEGER,
+	title             TEXT NOT NULL,
+	text              TEXT NOT NULL,
+	answer            TEXT NOT NULL,
+	community_answer  TEXT,
+	community_share   REAL,
+	timestamp         TEXT,
+	origin            TEXT,
+	first_export_id   INTEGER NOT NULL REFERENCES exports (id),
+	last_export_id    INTEGER NOT NULL REFERENCES exports (id)
+);
+
+CREATE TABLE IF NOT EXISTS choices (
+	question_id INTEGER NOT NULL REFERENCES questions (id) ON DELETE CASCADE,
+	letter      TEXT NOT NULL,
+	text        TEXT NOT NULL,
+	PRIMARY KEY (question_id, letter)
+);
+
+CREATE TABLE IF NOT EXISTS votes (
+	question_id INTEGER NOT NULL REFERENCES questions (id) ON DELETE CASCADE,
+	answer      TEXT NOT NULL,
+	count       INTEGER NOT NULL,
+	most_voted  INTEGER NOT NULL,
+	PRIMARY KEY (question_id, answer)
+);
+
+CREATE TABLE IF NOT EXISTS images (
+	question_id INTEGER NOT NULL REFERENCES questions (id) ON DELETE CASCADE,
+	position    INTEGER NOT NULL,
+	url         TEXT NOT NULL,
+	PRIMARY KEY (question_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id              INTEGER PRIMARY KEY,
+	question_id     INTEGER NOT NULL REFERENCES questions (id) ON DELETE CASCADE,
+	poster          TEXT NOT NULL,
+	content         TEXT NOT NULL,
+	upvotes         INTEGER NOT NULL,
+	timestamp       TEXT,
+	selected_answer TEXT,
+	first_export_id INTEGER NOT NULL REFERENCES exports (id),
+	UNIQUE (question_id, poster, content)
+);
+
+CREATE TABLE IF NOT EXISTS answer_history (
+	id               INTEGER PRIMARY KEY,
+	question_id      INTEGER NOT NULL REFERENCES questions (id) ON DELETE CASCADE,
+	export_id        INTEGER NOT NULL REFERENCES exports (id),
+	answer           TEXT NOT NULL,
+	community_answer TEXT,
+	community_share  REAL
+);
+
+PRAGMA user_version = 1;
+`
+
+// Creates or updates a SQLite database at path, upserting questions by their link.
+// Every run is recorded in exports, and answer_history gets a row whenever a question's
+// suggested or community answer changes
+func writeSQLite(dataList []models.QuestionData, path string, opts Options) error {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create the schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	data := NewTemplateData(dataList, opts.Comments)
+	result, err := tx.Exec("INSERT INTO exports (exported_at, questions) VALUES (?, ?)",
+		data.Generated.UTC().Format(time.RFC3339), len(data.Questions))
+	if err != nil {
+		return err
+	}
+	exportID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	exams := make(map[string]int64)
+	for _, question := range data.Questions {
+		if err := upsertQuestion(tx, exams, exportID, question); err != nil {
+			return fmt.Errorf("failed to store %s: %w", question.Link, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func upsertQuestion(tx *sql.Tx, exams map[string]int64, exportID int64, question TemplateQuestion) error {
+	ref, ok := utils.ParseQuestionLink(question.Link)
+	var examID sql.NullInt64
+	if ok {
+		id, err := upsertExam(tx, exams, ref.Provider, ref.Exam)
+		if err != nil {
+			return err
+		}
+		examID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	var questionID int64
+	err := tx.QueryRow(`
+		INSERT INTO questions (url, exam_id, topic, number, title, text, answer, community_answer,
+			community_share, timestamp, origin, first_export_id, last_export_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (url) DO UPDATE SET
+			exam_id = excluded.exam_id,
+			topic = excluded.topic,
+			number = excluded.number,
+			title = excluded.title,
+			text = excluded.text,
+			answer = excluded.answer,
+			community_answer = excluded.community_answer,
+			community_share = excluded.community_share,
+			timestamp = excluded.timestamp,
+			origin = COALESCE(excluded.origin, questions.origin),
+			last_export_id = excluded.last_export_id
+		RETURNING id`,
+		utils.NormalizeQuestionURL(question.Link), examID, nullInt(question.Topic), nullInt(question.QuestionNumber),
+		question.Title, question.Text, question.Answer, nullString(question.CommunityAnswer),
+		question.CommunityShare, nullString(question.Timestamp), nullString(question.Data.Origin), exportID, exportID,
+	).Scan(&questionID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordAnswer(tx, questionID, exportID, question); err != nil {
+		return err
+	}
+
+	// Choices, votes and images describe the current state, so they are replaced
+	for _, table := range []string{"choices", "votes", "images"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE question_id = ?", questionID); err != nil {
+			return err
+		}
+	}
+	for _, choice := range question.Choices {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO choices (question_id, letter, text) VALUES (?, ?, ?)",
+			questionID, choice.Letter, choice.Text); err != nil {
+			return err
+		}
+	}
+	for _, vote := range question.Votes {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO votes (question_id, answer, count, most_voted) VALUES (?, ?, ?, ?)",
+			questionID, vote.Answer, vote.Count, vote.MostVoted); err != nil {
+			return err
+		}
+	}
+	for i, image := range question.Images {
+		if _, err := tx.Exec("INSERT INTO images (question_id, position, url) VALUES (?, ?, ?)",
+			questionID, i+1, image); err != nil {
+			return err
+		}
+	}
+
+	// Comments are kept across exports, only their upvotes are refreshed
+	for _, comment := range question.Comments {
+		_, err := tx.Exec(`
+			INSERT INTO comments (question_id, poster, content, upvotes, timestamp, selected_answer, first_export_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (question_id, poster, content) DO UPDATE SET upvotes = excluded.upvotes`,
+			questionID, comment.Poster, comment.Content, comment.Upvotes,
+			nullString(comment.Timestamp), nullString(comment.SelectedAnswer), exportID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func upsertExam(tx *sql.Tx, exams map[string]int64, provider, slug string) (int64, error) {
+	key := provider + "/" + slug
+	if id, ok := exams[key]; ok {
+		return id, nil
+	}
+
+	var id int64
+	err := tx.QueryRow(`
+		INSERT INTO exams (provider, slug) VALUES (?, ?)
+		ON CONFLICT (provider, slug) DO UPDATE SET provider = excluded.provider
+		RETURNING id`, provider, slug).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	exams[key] = id
+	return id, nil
+}
+
+// Adds an answer_history row when the answers differ from the last recorded ones
+func recordAnswer(tx *sql.Tx, questionID, exportID int64, question TemplateQuestion) error {
+	var answer string
+	var community sql.NullString
+	err := tx.QueryRow(`
+		SELECT answer, community_answer FROM answer_history
+		WHERE question_id = ? ORDER BY id DESC LIMIT 1`, questionID).Scan(&answer, &community)
+	if err == nil && answer == question.Answer && community.String == question.CommunityAnswer {
+		return nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO answer_history (question_id, export_id, answer, community_answer, community_share)
+		VALUES (?, ?, ?, ?, ?)`,
+		questionID, exportID, question.Answer, nullString(question.CommunityAnswer), question.CommunityShare)
+	return err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i > 0}
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// TemplateData is the value a user template is executed with
+type TemplateData struct {
+	Provider  string
+	Exam      string
+	Generated time.Time
+	Comments  bool
+	Questions []TemplateQuestion
+}
+
+// TemplateQuestion is one question with its fields already parsed for templates
+type TemplateQuestion struct {
+	Number          int
+	Topic           int
+	QuestionNumber  int
+	Title           string
+	Text            string
+	Images          []string
+	Choices         []models.Choice
+	Answer          string
+	CommunityAnswer string
+	CommunityShare  float64
+	Votes           []models.Vote
+	Comments        []models.Comment
+	CommentText     string
+	Timestamp       string
+	Link            string
+	Duplicates      []string
+	Data            models.QuestionData
+}
+
+// Builds the template data model from the questions, dropping comments unless asked for
+func NewTemplateData(dataList []models.QuestionData, comments bool) TemplateData {
+	data := TemplateData{Generated: time.Now(), Comments: comments}
+
+	number := 0
+	for _, question := range dataList {
+		if question.Title == "" {
+			continue
+		}
+		number++
+
+		ref, _ := utils.ParseQuestionLink(question.QuestionLink)
+		if data.Exam == "" {
+			data.Provider, data.Exam = ref.Provider, ref.Exam
+		}
+
+		community, share := utils.CommunityAnswer(question.Votes)
+		item := TemplateQuestion{
+			Number:          number,
+			Topic:           ref.Topic,
+			QuestionNumber:  ref.Number,
+			Title:           question.Title,
+			Text:            utils.QuestionBody(question),
+			Images:          utils.QuestionImages(question),
+			Choices:         utils.ParseChoices(question.Questions),
+			Answer:          utils.NormalizeAnswer(question.Answer),
+			CommunityAnswer: utils.NormalizeAnswer(community),
+			CommunityShare:  share,
+			Votes:           question.Votes,
+			Timestamp:       question.Timestamp,
+			Link:            question.QuestionLink,
+			Duplicates:      question.Duplicates,
+			Data:            question,
+		}
+		if comments {
+			item.Comments = question.Discussion
+			item.CommentText = question.Comments
+		} else {
+			item.Data.Comments = ""
+			item.Data.Discussion = nil
+		}
+		data.Questions = append(data.Questions, item)
+	}
+	return data
+}
+
+// Helper functions available to every template
+var templateFuncs = map[string]any{
+	"join":      strings.Join,
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"trim":      strings.TrimSpace,
+	"replace":   strings.ReplaceAll,
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"split":     strings.Split,
+	"lines":     func(s string) []string { return strings.Split(strings.TrimSpace(s), "\n") },
+	"add":       func(a, b int) int { return a + b },
+	"percent":   func(share float64) string { return fmt.Sprintf("%.0f%%", share*100) },
+	"isAnswer": func(letter, answer string) bool {
+		return letter != "" && strings.Contains(utils.NormalizeAnswer(answer), strings.ToUpper(letter))
+	},
+	"votes": utils.FormatVotes,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+	"default": func(fallback, value string) string {
+		if strings.TrimSpace(value) == "" {
+			return fallback
+		}
+		return value
+	},
+	"latex":      latexEscape,
+	"markdownID": utils.MarkdownAnchor,
+}
+
+// Renders the questions through the user template at templatePath. Templates ending in
+// .html, .htm or .gohtml use html/template, everything else text/template
+func writeTemplate(dataList []models.QuestionData, path, templatePath string, comments bool) error {
+	source, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	file := utils.CreateFile(path)
+	defer file.Close()
+
+	return executeTemplate(file, templatePath, string(source), NewTemplateData(dataList, comments))
+}
+
+func executeTemplate(w io.Writer, name, source string, data TemplateData) error {
+	name = filepath.Base(name)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm", ".gohtml":
+		tmpl, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		return tmpl.Execute(w, data)
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl.Execute(w, data)
+}
+
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`^`, `\textasciicircum{}`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`%`, `\%`,
+)
+
+func latexEscape(s string) string {
+	return latexReplacer.Replace(s)
+}
@@ -0,0 +1,247 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"examtopics-downloader/internal/dedupe"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Heading of the section in every note that is kept as is when re-exporting
+const vaultNotesHeading = "## Notes"
+
+// vaultFrontMatter is the YAML front matter of a question note
+type vaultFrontMatter struct {
+	Exam            string   `yaml:"exam,omitempty"`
+	Provider        string   `yaml:"provider,omitempty"`
+	Topic           int      `yaml:"topic,omitempty"`
+	Number          int      `yaml:"number,omitempty"`
+	Answer          string   `yaml:"answer"`
+	CommunityAnswer string   `yaml:"community_answer,omitempty"`
+	CommunityShare  int      `yaml:"community_share,omitempty"`
+	Timestamp       string   `yaml:"timestamp,omitempty"`
+	Tags            []string `yaml:"tags"`
+	Source          string   `yaml:"source"`
+}
+
+// Writes an Obsidian/Logseq vault into the directory at path: a note per question with
+// front matter, an index note per exam and wiki-links between near-duplicates. Anything
+// under "## Notes" in an existing note is carried over
+func writeVault(dataList []models.QuestionData, dir string, opts Options) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	dataList = dedupe.Annotate(dataList, dedupe.FindGroups(dataList, dedupe.DefaultThreshold))
+	data := NewTemplateData(dataList, opts.Comments)
+
+	names := make([]string, len(data.Questions))
+	byLink := make(map[string]string, len(data.Questions))
+	used := make(map[string]int)
+	for i, question := range data.Questions {
+		name := vaultNoteName(question)
+		if count := used[strings.ToLower(name)]; count > 0 {
+			name = fmt.Sprintf("%s (%d)", name, count+1)
+		}
+		used[strings.ToLower(name)]++
+		names[i] = name
+		byLink[utils.NormalizeQuestionURL(question.Link)] = name
+	}
+
+	for i, question := range data.Questions {
+		path := filepath.Join(dir, names[i]+".md")
+		note, err := vaultNote(question, byLink, existingNotes(path))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, note, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return writeVaultIndexes(data, names, dir)
+}
+
+// Names a note after its exam, topic and question number, e.g. "AZ-104 T1 Q12"
+func vaultNoteName(question TemplateQuestion) string {
+	if ref, ok := utils.ParseQuestionLink(question.Link); ok && ref.Exam != "" && question.Topic > 0 {
+		return fmt.Sprintf("%s T%d Q%d", strings.ToUpper(ref.Exam), question.Topic, question.QuestionNumber)
+	}
+
+	// Characters Obsidian doesn't allow in note names
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`*"\/<>:|?#^[]`, r) {
+			return -1
+		}
+		return r
+	}, question.Title)
+	if name = strings.TrimSpace(name); name == "" {
+		name = fmt.Sprintf("Question %d", question.Number)
+	}
+	return name
+}
+
+func vaultNote(question TemplateQuestion, byLink map[string]string, notes string) ([]byte, error) {
+	ref, _ := utils.ParseQuestionLink(question.Link)
+	front := vaultFrontMatter{
+		Exam:            ref.Exam,
+		Provider:        ref.Provider,
+		Topic:           question.Topic,
+		Number:          question.QuestionNumber,
+		Answer:          question.Answer,
+		CommunityAnswer: question.CommunityAnswer,
+		Timestamp:       question.Timestamp,
+		Tags:            []string{"examtopics"},
+		Source:          question.Link,
+	}
+	if question.CommunityAnswer != "" {
+		front.CommunityShare = int(question.CommunityShare*100 + 0.5)
+	}
+	if ref.Exam != "" {
+		front.Tags = append(front.Tags, ref.Exam)
+	}
+	if question.Topic > 0 {
+		front.Tags = append(front.Tags, fmt.Sprintf("topic-%d", question.Topic))
+	}
+	if question.CommunityAnswer != "" && question.CommunityAnswer != question.Answer {
+		front.Tags = append(front.Tags, "disputed")
+	}
+
+	header, err := yaml.Marshal(front)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\n%s---\n\n", header)
+	fmt.Fprintf(&b, "# %s\n\n", question.Title)
+	if text := strings.TrimSpace(question.Text); text != "" {
+		fmt.Fprintf(&b, "%s\n\n", text)
+	}
+	for _, image := range question.Images {
+		fmt.Fprintf(&b, "![](%s)\n\n", image)
+	}
+
+	for _, choice := range question.Choices {
+		fmt.Fprintf(&b, "- **%s.** %s\n", choice.Letter, choice.Text)
+	}
+	if len(question.Choices) == 0 {
+		for _, text := range question.Data.Questions {
+			fmt.Fprintf(&b, "%s\n", text)
+		}
+	}
+	b.WriteString("\n")
+
+	// A folded callout, so the answer stays hidden until clicked
+	fmt.Fprintf(&b, "> [!success]- Answer\n> **%s**", question.Answer)
+	if question.CommunityAnswer != "" {
+		fmt.Fprintf(&b, ", community: **%s** (%d%%)", question.CommunityAnswer, front.CommunityShare)
+	}
+	b.WriteString("\n")
+	if len(question.Votes) > 0 {
+		fmt.Fprintf(&b, "> \n> Community vote: %s\n", utils.FormatVotes(question.Votes))
+	}
+	b.WriteString("\n")
+
+	if len(question.Duplicates) > 0 {
+		var links []string
+		for _, link := range question.Duplicates {
+			if name, ok := byLink[utils.NormalizeQuestionURL(link)]; ok {
+				links = append(links, "[["+name+"]]")
+			} else {
+				links = append(links, link)
+			}
+		}
+		fmt.Fprintf(&b, "Similar questions: %s\n\n", strings.Join(links, ", "))
+	}
+	fmt.Fprintf(&b, "[View on ExamTopics](%s)\n\n", question.Link)
+
+	if len(question.Comments) > 0 {
+		b.WriteString("## Discussion\n\n")
+		for _, comment := range question.Comments {
+			fmt.Fprintf(&b, "> **%s** (%d upvotes)", comment.Poster, comment.Upvotes)
+			if comment.SelectedAnswer != "" {
+				fmt.Fprintf(&b, ", selected %s", comment.SelectedAnswer)
+			}
+			fmt.Fprintf(&b, "\n> %s\n\n", strings.ReplaceAll(strings.TrimSpace(comment.Content), "\n", "\n> "))
+		}
+	}
+
+	if notes == "" {
+		notes = vaultNotesHeading + "\n\n"
+	}
+	b.WriteString(notes)
+	return b.Bytes(), nil
+}
+
+// Returns the notes section of an existing note, from its heading to the end
+func existingNotes(path string) string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	content := string(raw)
+	if strings.HasPrefix(content, vaultNotesHeading+"\n") {
+		return content
+	}
+	if idx := strings.Index(content, "\n"+vaultNotesHeading+"\n"); idx >= 0 {
+		return content[idx+1:]
+	}
+	return ""
+}
+
+// Writes an index note per exam, listing every question note by topic. Questions
+// without an exam in their link share a general index
+func writeVaultIndexes(data TemplateData, names []string, dir string) error {
+	var exams []string
+	byExam := make(map[string][]int)
+	for i, question := range data.Questions {
+		ref, _ := utils.ParseQuestionLink(question.Link)
+		if _, ok := byExam[ref.Exam]; !ok {
+			exams = append(exams, ref.Exam)
+		}
+		byExam[ref.Exam] = append(byExam[ref.Exam], i)
+	}
+
+	for _, exam := range exams {
+		if err := writeVaultIndex(exam, data.Questions, byExam[exam], names, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeVaultIndex(exam string, questions []TemplateQuestion, indexes []int, names []string, dir string) error {
+	title := "Exam Topics Questions"
+	if exam != "" {
+		title = strings.ToUpper(exam)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\ntags:\n  - examtopics\n  - index\n---\n\n# %s\n\n", title)
+	fmt.Fprintf(&b, "%d questions.\n", len(indexes))
+
+	topic := -1
+	for _, i := range indexes {
+		question := questions[i]
+		if question.Topic != topic {
+			topic = question.Topic
+			if topic > 0 {
+				fmt.Fprintf(&b, "\n## Topic %d\n\n", topic)
+			} else {
+				b.WriteString("\n## Other questions\n\n")
+			}
+		}
+		fmt.Fprintf(&b, "- [[%s]] %s\n", names[i], question.Answer)
+	}
+
+	return os.WriteFile(filepath.Join(dir, title+".md"), b.Bytes(), 0o644)
+}
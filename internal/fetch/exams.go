@@ -0,0 +1,131 @@
+package fetch
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"examtopics-downloader/internal/constants"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var providerLinkRe = regexp.MustCompile(`^/exams/([a-z0-9-]+)/?$`)
+var questionCountRe = regexp.MustCompile(`(?i)(\d[\d,]*)\s+questions?`)
+
+// Lists every provider linked from the examtopics exams index
+func ListProviders() ([]string, error) {
+	doc, err := ParseHTML("https://www.examtopics.com/exams/", *client)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []string
+	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+		href := strings.ToLower(strings.TrimSpace(s.AttrOr("href", "")))
+		href = strings.TrimPrefix(href, "https://www.examtopics.com")
+		if match := providerLinkRe.FindStringSubmatch(href); match != nil {
+			providers = append(providers, match[1])
+		}
+	})
+
+	providers = utils.DeduplicateLinks(providers)
+	sort.Strings(providers)
+	return providers, nil
+}
+
+// Lists every exam on a provider's page with its name and, when shown, its question count
+func ListProviderExamInfo(providerName string) ([]models.ExamInfo, error) {
+	baseURL := fmt.Sprintf("https://www.examtopics.com/exams/%s/", providerName)
+	doc, err := ParseHTML(baseURL, *client)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("/exams/%s/", strings.ToLower(providerName))
+	seen := make(map[string]int)
+	var exams []models.ExamInfo
+	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimPrefix(strings.TrimSpace(s.AttrOr("href", "")), "https://www.examtopics.com")
+		rest := strings.Trim(strings.TrimPrefix(strings.ToLower(href), prefix), "/")
+		if !strings.HasPrefix(strings.ToLower(href), prefix) || rest == "" || strings.Contains(rest, "/") {
+			return
+		}
+
+		info := models.ExamInfo{
+			Provider: providerName,
+			Slug:     rest,
+			Name:     utils.CleanText(s.Text()),
+			URL:      utils.AddToBaseUrl(prefix + rest + "/"),
+		}
+		if match := questionCountRe.FindStringSubmatch(s.Parent().Text()); match != nil {
+			info.QuestionCount, _ = strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+		}
+
+		// The same exam is often linked twice (popular list and full list), keep the richest entry
+		if idx, ok := seen[rest]; ok {
+			if exams[idx].Name == "" {
+				exams[idx].Name = info.Name
+			}
+			exams[idx].QuestionCount = max(exams[idx].QuestionCount, info.QuestionCount)
+			return
+		}
+		seen[rest] = len(exams)
+		exams = append(exams, info)
+	})
+
+	return exams, nil
+}
+
+// Searches the exams of every provider for a code or name
+func SearchExams(query string) ([]models.ExamInfo, error) {
+	providers, err := ListProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, constants.MaxConcurrentRequests)
+
+	var matches []models.ExamInfo
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(provider string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			<-rateLimiter.C
+
+			exams, err := ListProviderExamInfo(provider)
+			if err != nil {
+				log.Printf("failed to list exams for provider '%s': %v", provider, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, exam := range exams {
+				if utils.GrepString(exam.Slug, query) || utils.GrepString(exam.Name, query) || len(utils.MatchExamSlugs(query, []string{exam.Slug})) > 0 {
+					matches = append(matches, exam)
+				}
+			}
+		}(provider)
+	}
+	wg.Wait()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Provider != matches[j].Provider {
+			return matches[i].Provider < matches[j].Provider
+		}
+		return matches[i].Slug < matches[j].Slug
+	})
+	return matches, nil
+}
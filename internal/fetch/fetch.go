@@ -20,6 +20,16 @@ import (
 
 var client = &http.Client{Timeout: constants.HttpTimeout}
 
+// Replaces the HTTP client used for every request, returning the previous one
+func SetHTTPClient(c *http.Client) *http.Client {
+	previous := client
+	client = c
+	return previous
+}
+
+// Shared by every request to the live site, so concurrent crawls stay within the same rate
+var rateLimiter = utils.CreateRateLimiter(constants.RequestsPerSecond)
+
 func FetchURL(url string, client http.Client) []byte {
 	backoff := constants.InitalBackoff
 
@@ -73,14 +83,20 @@ func ParseHTML(url string, client http.Client) (*goquery.Document, error) {
 }
 
 // Fetches total number of pages
-func getMaxNumPages(url string) int {
+func getMaxNumPages(url string) (int, error) {
 	doc, err := ParseHTML(url, *client)
 	if err != nil {
-		log.Panicf("Failed parsing HTML for number of pages: %v", err)
+		return 0, fmt.Errorf("failed parsing HTML for number of pages: %w", err)
+	}
+
+	indicator := doc.Find(selectors.PageCount)
+	if indicator.Length() == 0 {
+		return 0, layoutError(url, "page count", selectors.PageCount)
 	}
 
+	// The indicator reads "Page 1 of N"
 	var pageCount int
-	doc.Find(".discussion-list-page-indicator strong").Each(func(i int, s *goquery.Selection) {
+	indicator.Each(func(i int, s *goquery.Selection) {
 		if i == 1 {
 			pageCount, _ = strconv.Atoi(strings.TrimSpace(s.Text()))
 		}
@@ -91,14 +107,22 @@ func getMaxNumPages(url string) int {
 		pageCount = 1
 	}
 
-	return pageCount
+	return pageCount, nil
 }
 
 func GetProviderExams(providerName string) []string {
+	allExams, err := ListProviderExams(providerName)
+	if err != nil {
+		log.Fatalf("Failed to parse HTML for provider exams: %v", err)
+	}
+	return allExams
+}
+
+func ListProviderExams(providerName string) ([]string, error) {
 	baseURL := fmt.Sprintf("https://www.examtopics.com/exams/%s/", providerName)
 	doc, err := ParseHTML(baseURL, *client)
 	if err != nil {
-		log.Fatalf("Failed to parse HTML for provider exams: %v", err)
+		return nil, err
 	}
 
 	var allExams []string
@@ -109,53 +133,99 @@ func GetProviderExams(providerName string) []string {
 		}
 	})
 
-	return allExams
+	return allExams, nil
+}
+
+// ExamMatchError is returned when a search string matches no exam or more than one
+type ExamMatchError struct {
+	Query      string
+	Provider   string
+	Candidates []string
+	Ambiguous  bool
+}
+
+func (e *ExamMatchError) Error() string {
+	if e.Ambiguous {
+		return fmt.Sprintf("%q matches %d exams for provider '%s', be more specific", e.Query, len(e.Candidates), e.Provider)
+	}
+	return fmt.Sprintf("no exam matching %q for provider '%s'", e.Query, e.Provider)
+}
+
+// Resolves a search string to a single exam slug, out of every exam the provider lists
+func ResolveExam(providerName, query string) (string, error) {
+	exams, err := ListProviderExamInfo(providerName)
+	if err != nil {
+		return "", err
+	}
+	if len(exams) == 0 {
+		return "", fmt.Errorf("no exams listed for provider '%s'", providerName)
+	}
+
+	var slugs []string
+	for _, exam := range exams {
+		slugs = append(slugs, exam.Slug)
+	}
+	return MatchExam(providerName, query, slugs)
+}
+
+// Picks the single exam slug matching a search string, or returns an *ExamMatchError
+func MatchExam(providerName, query string, slugs []string) (string, error) {
+	matches := utils.MatchExamSlugs(query, slugs)
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", &ExamMatchError{Query: query, Provider: providerName, Candidates: utils.ClosestExamSlugs(query, slugs, 5)}
+	}
+	return "", &ExamMatchError{Query: query, Provider: providerName, Candidates: matches, Ambiguous: true}
 }
 
 // Extracts matching links from a single page
-func getLinksFromPage(url string, grepStr string) []string {
+func getLinksFromPage(url string, grepStr string) ([]string, error) {
 	doc, err := ParseHTML(url, *client)
 	if err != nil {
-		log.Printf("Failed to parse HTML for %s: %v", url, err)
-		return nil
+		return nil, err
+	}
+
+	links := doc.Find(selectors.DiscussionLinks)
+	if links.Length() == 0 {
+		return nil, layoutError(url, "discussion links", selectors.DiscussionLinks)
 	}
 
 	var matchingLinks []string
-	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+	links.Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
-		if exists && utils.GrepString(href, "/discussions") && utils.GrepString(href, grepStr) {
+		if exists && utils.GrepString(href, "/discussions") && utils.MatchesExam(href, grepStr) {
 			matchingLinks = append(matchingLinks, href)
 		}
 	})
 
-	return matchingLinks
+	return matchingLinks, nil
 }
 
-func FetchCachedLinks(providerName string, grepStr string, token string) []string {
+// Lists the cached data files of a provider. Requests go through the shared client, so
+// a GitHub token is set once with SetHTTPClient rather than per call
+func FetchCachedLinks(providerName string, grepStr string) ([]string, error) {
 	parsedProviderName := utils.CapitalizeFirstLetter(strings.ToLower(providerName))
 	baseURL := fmt.Sprintf("https://api.github.com/repos/thatonecodes/examtopics-data/contents/%s", parsedProviderName)
-	if token != "" {
-		client = utils.NewGitHubClient(token)
-	}
 	resp := FetchURL(baseURL, *client)
 
 	var content []models.FileInfo
 
 	if resp == nil {
-		log.Printf("the response body was nil, %v", resp)
-		return nil
+		return nil, fmt.Errorf("no cached listing for provider '%s'", providerName)
 	}
 
 	err := json.Unmarshal(resp, &content)
 	if err != nil {
-		log.Fatalf("error unmarshaling response: %v", err)
+		return nil, fmt.Errorf("error unmarshaling response: %w", err)
 	}
 
 	var linksWithNumbers []models.FileInfo
 	for _, item := range content {
 		link := item.URL
 		number := utils.ExtractNumberFromPath(item.Name)
-		if utils.GrepStringFromCache(link, grepStr) {
+		if utils.MatchesCachedExam(link, grepStr) {
 			linksWithNumbers = append(linksWithNumbers, models.FileInfo{
 				URL:    link,
 				Name:   item.Name,
@@ -164,11 +234,19 @@ func FetchCachedLinks(providerName string, grepStr string, token string) []strin
 		}
 	}
 
-	return utils.SortCachedLinks(linksWithNumbers)
+	return utils.SortCachedLinks(linksWithNumbers), nil
+}
+
+func GetCachedPages(providerName string, grepStr string) ([]models.QuestionData, error) {
+	links, err := FetchCachedLinks(providerName, grepStr)
+	if err != nil {
+		return nil, err
+	}
+	return GetCachedPagesFromLinks(links), nil
 }
 
-func GetCachedPages(providerName string, grepStr string, token string) []models.QuestionData {
-	links := FetchCachedLinks(providerName, grepStr, token)
+// Loads the questions of cached data files listed by FetchCachedLinks
+func GetCachedPagesFromLinks(links []string) []models.QuestionData {
 	var allData []models.QuestionData
 
 	var wg sync.WaitGroup
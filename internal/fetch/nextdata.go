@@ -0,0 +1,96 @@
+package fetch
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Extracts a discussion page's question from its embedded page data when present,
+// falling back to the CSS selectors otherwise
+func parsePage(doc *goquery.Document, link string) (*models.QuestionData, error) {
+	if data, ok := parseNextData(doc, link); ok {
+		return data, nil
+	}
+	return parseQuestionDoc(doc, link)
+}
+
+// Reads the question from the Next.js data embedded in the page. ok is false when there
+// is no embedded data, or it doesn't hold this question with an answer
+func parseNextData(doc *goquery.Document, link string) (*models.QuestionData, bool) {
+	raw := strings.TrimSpace(doc.Find(selectors.NextData).First().Text())
+	if raw == "" {
+		return nil, false
+	}
+
+	var next models.NextData
+	if err := json.Unmarshal([]byte(raw), &next); err != nil {
+		log.Printf("failed to parse the page data of %s: %v", link, err)
+		return nil, false
+	}
+
+	props := next.Props.PageProps
+	candidates := props.Questions
+	if props.Question != nil {
+		candidates = append([]models.JSONQuestion{*props.Question}, candidates...)
+	}
+
+	want := utils.NormalizeQuestionURL(link)
+	for _, q := range candidates {
+		// A page holding a single question may leave out its own link
+		matches := utils.NormalizeQuestionURL(q.URL) == want || (q.URL == "" && len(candidates) == 1)
+		if !matches || (q.Answer == "" && q.AnswerET == "") {
+			continue
+		}
+
+		// Live pages may only fill in the answer of the exam view
+		q.Answer = cmp.Or(q.Answer, q.AnswerET)
+		q.QuestionImages = resolveLinks(q.QuestionImages, link)
+		data := questionFromJSON(q, link)
+		data.Title = utils.CleanText(doc.Find(selectors.Title).First().Text())
+		if data.Title == "" {
+			data.Title = titleFromLink(link)
+		}
+		data.QuestionLink = link
+		if votes := parseVoteTally(doc); len(votes) > 0 {
+			data.Votes = votes
+		}
+		data.Origin = "scrape"
+		return data, true
+	}
+	return nil, false
+}
+
+// Resolves relative links, such as the question images of a live page, against the page URL
+func resolveLinks(links []string, base string) []string {
+	baseURL, err := url.Parse(utils.NormalizeQuestionURL(base))
+	if err != nil {
+		return links
+	}
+
+	resolved := make([]string, len(links))
+	for i, link := range links {
+		resolved[i] = link
+		if ref, err := url.Parse(link); err == nil {
+			resolved[i] = baseURL.ResolveReference(ref).String()
+		}
+	}
+	return resolved
+}
+
+// Builds a title like the site's heading, e.g. "Exam az-104 topic 1 question 12 discussion"
+func titleFromLink(link string) string {
+	ref, ok := utils.ParseQuestionLink(link)
+	if !ok {
+		return link
+	}
+	return fmt.Sprintf("Exam %s topic %d question %d discussion", ref.Exam, ref.Topic, ref.Number)
+}
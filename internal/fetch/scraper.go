@@ -1,15 +1,20 @@
 package fetch
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	"examtopics-downloader/internal/constants"
+	"examtopics-downloader/internal/htmlmd"
 	"examtopics-downloader/internal/models"
 	"examtopics-downloader/internal/utils"
 
@@ -17,34 +22,89 @@ import (
 	"github.com/cheggaaa/pb/v3"
 )
 
-func getDataFromLink(link string) *models.QuestionData {
+func getDataFromLink(link string) (*models.QuestionData, error) {
 	doc, err := ParseHTML(link, *client)
 	if err != nil {
-		log.Printf("Failed parsing HTML data from link: %v", err)
-		return nil
+		return nil, err
+	}
+
+	return parsePage(doc, link)
+}
+
+// Extracts the question data from an already parsed discussion page, failing with a
+// LayoutError when the title or answer can't be found
+func parseQuestionDoc(doc *goquery.Document, link string) (*models.QuestionData, error) {
+	title := doc.Find(selectors.Title)
+	if strings.TrimSpace(title.Text()) == "" {
+		return nil, layoutError(link, "title", selectors.Title)
+	}
+	answerSel := doc.Find(selectors.Answer)
+	if answerSel.Length() == 0 {
+		return nil, layoutError(link, "answer", selectors.Answer)
 	}
+	// Every letter is kept for multiple answer questions. HOTSPOT and DRAG DROP answers
+	// are only an image, which leaves the answer empty
+	answer := utils.NormalizeAnswer(answerSel.Text())
 
 	var allQuestions []string
-	doc.Find("li.multi-choice-item").Each(func(i int, s *goquery.Selection) {
+	doc.Find(selectors.Choices).Each(func(i int, s *goquery.Selection) {
 		allQuestions = append(allQuestions, utils.CleanText(s.Text()))
 	})
 
-	answerText := strings.TrimSpace(doc.Find(".correct-answer").Text())
-	answer := ""
-	if len(answerText) > 0 {
-		answer = string(strings.ReplaceAll(strings.ReplaceAll(answerText, " ", ""), "\n", "")[0])
+	var discussion []models.Comment
+	doc.Find(selectors.Comment).Each(func(i int, s *goquery.Selection) {
+		upvotes, _ := strconv.Atoi(strings.TrimSpace(s.Find(selectors.CommentUpvotes).First().Text()))
+		discussion = append(discussion, models.Comment{
+			Poster:         utils.CleanText(s.Find(selectors.CommentPoster).First().Text()),
+			Content:        utils.CleanText(s.Find(selectors.CommentContent).First().Text()),
+			Upvotes:        upvotes,
+			Timestamp:      s.Find(selectors.CommentDate).First().AttrOr("title", ""),
+			SelectedAnswer: utils.ParseSelectedAnswer(s.Find(selectors.CommentSelected).First().Text()),
+		})
+	})
+
+	votes := parseVoteTally(doc)
+	if len(votes) == 0 {
+		votes = utils.VotesFromComments(discussion)
 	}
 
 	return &models.QuestionData{
-		Title:        utils.CleanText(doc.Find("h1").Text()),
-		Header:       strings.ReplaceAll(strings.TrimSpace(doc.Find(".question-discussion-header").Text()), "\t", ""),
-		Content:      utils.CleanText(doc.Find(".card-text").Text()),
+		Title:        utils.CleanText(title.Text()),
+		Header:       strings.ReplaceAll(strings.TrimSpace(doc.Find(selectors.Header).Text()), "\t", ""),
+		Content:      htmlmd.Convert(doc.Find(selectors.Content), link),
 		Questions:    allQuestions,
 		Answer:       answer,
-		Timestamp:    utils.CleanText(doc.Find(".discussion-meta-data > i").Text()),
+		Timestamp:    utils.CleanText(doc.Find(selectors.Timestamp).Text()),
 		QuestionLink: link,
-		Comments:     utils.CleanText(doc.Find(".discussion-container").Text()),
+		Comments:     utils.CleanText(doc.Find(selectors.Discussion).Text()),
+		Discussion:   discussion,
+		Votes:        votes,
+		Origin:       "scrape",
+	}, nil
+}
+
+// Reads the community vote distribution the site embeds as JSON next to the answer
+func parseVoteTally(doc *goquery.Document) []models.Vote {
+	raw := strings.TrimSpace(doc.Find(selectors.VoteTally).First().Text())
+	if raw == "" {
+		return nil
+	}
+
+	var tally []struct {
+		VotedAnswers string `json:"voted_answers"`
+		VoteCount    int    `json:"vote_count"`
+		IsMostVoted  bool   `json:"is_most_voted"`
 	}
+	if err := json.Unmarshal([]byte(raw), &tally); err != nil {
+		log.Printf("failed to parse vote tally: %v", err)
+		return nil
+	}
+
+	var votes []models.Vote
+	for _, entry := range tally {
+		votes = append(votes, models.Vote{Answer: entry.VotedAnswers, Count: entry.VoteCount, MostVoted: entry.IsMostVoted})
+	}
+	return votes
 }
 
 var counter int = 0 //start counter at 1
@@ -65,16 +125,20 @@ func getJSONFromLink(link string) []*models.QuestionData {
 	}
 
 	jsonResp := FetchURL(downloadURL, *client)
+	fmt.Println("Processing content from:", downloadURL)
 
+	return parseQuestionsJSON(jsonResp, link)
+}
+
+// Maps a cached pageProps JSON document onto question data
+func parseQuestionsJSON(data []byte, link string) []*models.QuestionData {
 	var content models.JSONResponse
-	err = json.Unmarshal(jsonResp, &content)
+	err := json.Unmarshal(data, &content)
 	if err != nil {
 		log.Printf("error unmarshalling the questions data: %v", err)
 		return nil
 	}
 
-	fmt.Println("Processing content from:", downloadURL)
-
 	var questions []*models.QuestionData
 
 	if content.PageProps.Questions == nil {
@@ -83,49 +147,73 @@ func getJSONFromLink(link string) []*models.QuestionData {
 	}
 
 	for _, q := range content.PageProps.Questions {
-		var comments string
-		for _, discussion := range q.Discussion {
-			comments += fmt.Sprintf("[%s] %s\n", discussion.Poster, discussion.Content)
-		}
-
-		var choicesHeader string
-		var keys []string
-		for key := range q.Choices {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			choicesHeader += fmt.Sprintf("**%s:** %s\n\n", key, q.Choices[key])
-		}
-
 		name := utils.GetNameFromLink(link)
 		counter++
 
-		questions = append(questions, &models.QuestionData{
-			Title:        "Examtopics " + strings.ReplaceAll(name, ".json?ref=main", "") + " question #" + strconv.Itoa(counter),
-			Header:       q.QuestionText,
-			Content:      strings.Join(q.QuestionImages, "\n"),
-			Questions:    []string{choicesHeader},
-			Answer:       q.Answer,
-			Timestamp:    q.Timestamp,
-			QuestionLink: q.URL,
-			Comments:     utils.CleanText(comments),
-		})
+		data := questionFromJSON(q, q.URL)
+		data.Title = "Examtopics " + strings.ReplaceAll(name, ".json?ref=main", "") + " question #" + strconv.Itoa(counter)
+		data.Origin = "cache"
+		data.Page = max(utils.ExtractNumberFromPath(path.Base(link)), 0)
+		questions = append(questions, data)
 	}
 
 	return questions
 }
 
-func fetchAllPageLinksConcurrently(providerName, grepStr string, numPages, concurrency int) []string {
+// Maps one question of the site's page data onto question data, leaving the title to the caller.
+// The HTML question text is converted to markdown, resolving relative links against base
+func questionFromJSON(q models.JSONQuestion, base string) *models.QuestionData {
+	var comments string
+	var discussion []models.Comment
+	for _, d := range q.Discussion {
+		comments += fmt.Sprintf("[%s] %s\n", d.Poster, d.Content)
+		upvotes, _ := strconv.Atoi(strings.TrimSpace(d.UpvoteCount))
+		discussion = append(discussion, models.Comment{
+			Poster:         d.Poster,
+			Content:        utils.CleanText(d.Content),
+			Upvotes:        upvotes,
+			Timestamp:      d.Timestamp,
+			SelectedAnswer: utils.ParseSelectedAnswer(d.Content),
+		})
+	}
+
+	var choicesHeader string
+	var keys []string
+	for key := range q.Choices {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		choicesHeader += fmt.Sprintf("**%s:** %s\n\n", key, q.Choices[key])
+	}
+
+	return &models.QuestionData{
+		Header:       htmlmd.FromString(q.QuestionText, base),
+		Content:      strings.Join(q.QuestionImages, "\n"),
+		Questions:    []string{choicesHeader},
+		Answer:       q.Answer,
+		Timestamp:    q.Timestamp,
+		QuestionLink: q.URL,
+		Comments:     utils.CleanText(comments),
+		Discussion:   discussion,
+		Votes:        utils.VotesFromComments(discussion),
+	}
+}
+
+// Collects the matching links of every discussion page. Pages that fail are logged and
+// skipped, but a LayoutError is returned since every other page will fail the same way
+func fetchAllPageLinksConcurrently(providerName, grepStr string, numPages, concurrency int) ([]string, error) {
+	type pageResult struct {
+		links []string
+		err   error
+	}
+
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, concurrency)
-	results := make(chan []string, numPages)
+	results := make(chan pageResult, numPages)
 	bar := pb.StartNew(numPages)
 	startTime := utils.StartTime()
 
-	rateLimiter := utils.CreateRateLimiter(constants.RequestsPerSecond)
-	defer rateLimiter.Stop()
-
 	for i := 1; i <= numPages; i++ {
 		wg.Add(1)
 		go func(i int) {
@@ -136,7 +224,8 @@ func fetchAllPageLinksConcurrently(providerName, grepStr string, numPages, concu
 			<-rateLimiter.C
 
 			url := fmt.Sprintf("https://www.examtopics.com/discussions/%s/%d", providerName, i)
-			results <- getLinksFromPage(url, grepStr)
+			links, err := getLinksFromPage(url, grepStr)
+			results <- pageResult{links, err}
 			bar.Increment()
 		}(i)
 	}
@@ -147,38 +236,77 @@ func fetchAllPageLinksConcurrently(providerName, grepStr string, numPages, concu
 	}()
 
 	var all []string
+	var layoutErr error
 	for res := range results {
-		all = append(all, res...)
+		all = append(all, res.links...)
+		if res.err == nil {
+			continue
+		}
+		if errors.Is(res.err, ErrLayoutChanged) {
+			layoutErr = cmp.Or(layoutErr, res.err)
+		} else {
+			log.Printf("Failed to parse HTML: %v", res.err)
+		}
 	}
 
 	bar.Finish()
 	fmt.Printf("Scraping completed in %s.\n", utils.TimeSince(startTime))
-	return all
+	return all, layoutErr
 }
 
-// Main concurrent page scraping logic
-func GetAllPages(providerName string, grepStr string) []models.QuestionData {
+// Crawls every discussion page of a provider and returns the sorted, unique matching links
+func getDiscussionLinks(providerName string, grepStr string) ([]string, error) {
 	baseURL := fmt.Sprintf("https://www.examtopics.com/discussions/%s/", providerName)
-	numPages := getMaxNumPages(baseURL)
+	numPages, err := getMaxNumPages(baseURL)
+	if err != nil {
+		return nil, err
+	}
 	fmt.Printf("Fetching %d pages for provider '%s'\n", numPages, providerName)
 
-	allLinks := fetchAllPageLinksConcurrently(providerName, grepStr, numPages, constants.MaxConcurrentRequests)
+	allLinks, err := fetchAllPageLinksConcurrently(providerName, grepStr, numPages, constants.MaxConcurrentRequests)
+	if err != nil {
+		return nil, err
+	}
 
 	unique := utils.DeduplicateLinks(allLinks)
-	sortedLinks := utils.SortLinksByQuestionNumber(unique)
+	return utils.SortLinksByQuestionNumber(unique), nil
+}
+
+// Main concurrent page scraping logic
+func GetAllPages(providerName string, grepStr string) []models.QuestionData {
+	questions, err := ScrapeAllPages(providerName, grepStr)
+	if err != nil {
+		log.Panicf("Failed to scrape the discussions: %v", err)
+	}
+	return questions
+}
+
+// Crawls the discussion pages of a provider and scrapes every matching question
+func ScrapeAllPages(providerName string, grepStr string) ([]models.QuestionData, error) {
+	sortedLinks, err := getDiscussionLinks(providerName, grepStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the discussions: %w", err)
+	}
 
 	fmt.Printf("Found %d unique matching links:\n", len(sortedLinks))
 
+	return scrapeLinks(sortedLinks)
+}
+
+// Scrapes the given discussion links concurrently, keeping their order
+func scrapeLinks(links []string) ([]models.QuestionData, error) {
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, constants.MaxConcurrentRequests)
-	results := make([]*models.QuestionData, len(sortedLinks))
+	results := make([]*models.QuestionData, len(links))
 	startTime := utils.StartTime()
-	bar := pb.StartNew(len(sortedLinks))
+	bar := pb.StartNew(len(links))
 
-	rateLimiter := utils.CreateRateLimiter(constants.RequestsPerSecond)
-	defer rateLimiter.Stop()
+	// A layout change breaks every page alike, so it is reported once rather than per link
+	var mu sync.Mutex
+	var layoutErr error
+	layoutFailures := 0
 
-	for i, link := range sortedLinks {
+	for i, link := range links {
 		wg.Add(1)
 		url := utils.AddToBaseUrl(link)
 
@@ -189,8 +317,16 @@ func GetAllPages(providerName string, grepStr string) []models.QuestionData {
 
 			<-rateLimiter.C
 
-			data := getDataFromLink(url)
-			if data != nil {
+			data, err := getDataFromLink(url)
+			switch {
+			case errors.Is(err, ErrLayoutChanged):
+				mu.Lock()
+				layoutErr = cmp.Or(layoutErr, err)
+				layoutFailures++
+				mu.Unlock()
+			case err != nil:
+				log.Printf("Failed parsing HTML data from link: %v", err)
+			default:
 				results[i] = data
 			}
 			bar.Increment()
@@ -199,15 +335,63 @@ func GetAllPages(providerName string, grepStr string) []models.QuestionData {
 
 	wg.Wait()
 	bar.Finish()
-	// Filter out nil entries
-	var finalData []models.QuestionData
-	for _, entry := range results {
-		if entry != nil {
-			finalData = append(finalData, *entry)
-		}
+	finalData := utils.FilterOutNilData(results)
+	fmt.Printf("Scraping completed in %s.\n", utils.TimeSince(startTime))
+
+	// A few odd pages are skipped, but most pages failing means the selectors are out of date
+	if layoutFailures*2 > len(links) {
+		return nil, fmt.Errorf("%d of %d questions could not be read: %w", layoutFailures, len(links), layoutErr)
+	}
+	if layoutErr != nil {
+		log.Printf("Skipped %d of %d questions: %v", layoutFailures, len(links), layoutErr)
 	}
+	return finalData, nil
+}
 
-	fmt.Printf("Scraping completed in %s.\n", utils.TimeSince(startTime))
+// Fetches through the chain with the live site added as its last source when missing. Unlike
+// a plain run, the listings of every source are joined, so the live questions the other
+// sources don't have are scraped
+func GetHybridPages(chain *Chain, providerName string, grepStr string) []models.QuestionData {
+	hybrid := chain
+	if !slices.ContainsFunc(chain.Sources, func(source Source) bool { return source.Name() == "scrape" }) {
+		hybrid = &Chain{Sources: append(slices.Clone(chain.Sources), NewScraperSource())}
+	}
+
+	listings, err := hybrid.Listings(providerName, grepStr)
+	if err != nil {
+		log.Printf("failed to list questions: %v", err)
+	}
+	links := MergeListings(listings)
+	if len(links) == 0 {
+		return nil
+	}
 
-	return finalData
+	questions := utils.FilterOutNilData(hybrid.FetchLinks(links, true))
+	return utils.SortQuestionsByLink(utils.DeduplicateQuestions(questions))
+}
+
+// Crawls the provider's discussion pages once and scrapes the questions of each exam slug
+func GetAllPagesForExams(providerName string, examSlugs []string) (map[string][]models.QuestionData, error) {
+	allLinks, err := getDiscussionLinks(providerName, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the discussions: %w", err)
+	}
+
+	results := make(map[string][]models.QuestionData, len(examSlugs))
+	for _, slug := range examSlugs {
+		var examLinks []string
+		for _, link := range allLinks {
+			if utils.MatchesExam(link, slug) {
+				examLinks = append(examLinks, link)
+			}
+		}
+
+		fmt.Printf("Found %d unique matching links for exam '%s'\n", len(examLinks), slug)
+		questions, err := scrapeLinks(examLinks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape exam '%s': %w", slug, err)
+		}
+		results[slug] = questions
+	}
+	return results, nil
 }
@@ -0,0 +1,110 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Version of the built-in selector profile, bumped whenever the site's markup changes
+const SelectorsVersion = 1
+
+// Selectors are the CSS selectors used to scrape the live site
+type Selectors struct {
+	Version int `json:"version"`
+
+	// Discussion page of a single question
+	Title           string `json:"title"`
+	Header          string `json:"header"`
+	Content         string `json:"content"`
+	Choices         string `json:"choices"`
+	Answer          string `json:"answer"`
+	Timestamp       string `json:"timestamp"`
+	Discussion      string `json:"discussion"`
+	Comment         string `json:"comment"`
+	CommentPoster   string `json:"comment_poster"`
+	CommentContent  string `json:"comment_content"`
+	CommentUpvotes  string `json:"comment_upvotes"`
+	CommentDate     string `json:"comment_date"`
+	CommentSelected string `json:"comment_selected"`
+	VoteTally       string `json:"vote_tally"`
+
+	// Page data embedded by Next.js, preferred over the selectors above when present
+	NextData string `json:"next_data"`
+
+	// Paginated list of a provider's discussions
+	DiscussionLinks string `json:"discussion_links"`
+	PageCount       string `json:"page_count"`
+}
+
+var DefaultSelectors = Selectors{
+	Version:         SelectorsVersion,
+	Title:           "h1",
+	Header:          ".question-discussion-header",
+	Content:         ".card-text",
+	Choices:         "li.multi-choice-item",
+	Answer:          ".correct-answer",
+	Timestamp:       ".discussion-meta-data > i",
+	Discussion:      ".discussion-container",
+	Comment:         ".comment-container",
+	CommentPoster:   ".comment-username",
+	CommentContent:  ".comment-content",
+	CommentUpvotes:  ".upvote-count",
+	CommentDate:     ".comment-date",
+	CommentSelected: ".comment-selected-answers",
+	VoteTally:       ".voted-answers-tally script",
+	NextData:        "script#__NEXT_DATA__",
+	DiscussionLinks: `a[href*="/discussions/"]`,
+	PageCount:       ".discussion-list-page-indicator strong",
+}
+
+// Selector profile used by the scraper, set once before scraping starts
+var selectors = DefaultSelectors
+
+// Sets the selector profile used to scrape the live site and saved pages
+func SetSelectors(s Selectors) {
+	selectors = s
+}
+
+// Reads a JSON selector profile. Selectors it leaves out keep their built-in value
+func LoadSelectors(path string) (Selectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Selectors{}, err
+	}
+
+	profile := DefaultSelectors
+	profile.Version = 0
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return Selectors{}, fmt.Errorf("failed to parse selector profile %s: %w", path, err)
+	}
+	if profile.Version > 0 && profile.Version < SelectorsVersion {
+		log.Printf("selector profile %s is for version %d, the built-in profile is version %d", path, profile.Version, SelectorsVersion)
+	}
+	return profile, nil
+}
+
+var ErrLayoutChanged = errors.New("site layout changed")
+
+// LayoutError is returned when a required selector matches nothing on a page
+type LayoutError struct {
+	URL      string
+	Field    string
+	Selector string
+	Version  int
+}
+
+func (e *LayoutError) Error() string {
+	return fmt.Sprintf("%v: the %s selector %q matched nothing on %s (selector profile version %d, override it with -selectors)",
+		ErrLayoutChanged, e.Field, e.Selector, e.URL, e.Version)
+}
+
+func (e *LayoutError) Unwrap() error {
+	return ErrLayoutChanged
+}
+
+func layoutError(url, field, selector string) *LayoutError {
+	return &LayoutError{URL: url, Field: field, Selector: selector, Version: selectors.Version}
+}
@@ -0,0 +1,316 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"examtopics-downloader/internal/constants"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+var ErrQuestionNotFound = errors.New("question not found in source")
+
+// Source is a backend that can enumerate exams and questions and fetch a single question
+type Source interface {
+	Name() string
+	ListExams(providerName string) ([]string, error)
+	ListQuestions(providerName, grepStr string) ([]string, error)
+	FetchQuestion(link string) (*models.QuestionData, error)
+}
+
+type SourceOptions struct {
+	LocalDir  string
+	MirrorDir string
+}
+
+// Builds the source registered under name
+func NewSource(name string, opts SourceOptions) (Source, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "cache":
+		return NewGitHubSource(), nil
+	case "scrape":
+		return NewScraperSource(), nil
+	case "local":
+		if opts.LocalDir == "" {
+			return nil, fmt.Errorf("source %q needs a directory (-local-dir)", name)
+		}
+		return NewLocalSource(opts.LocalDir), nil
+	case "mirror":
+		if opts.MirrorDir == "" {
+			return nil, fmt.Errorf("source %q needs a directory (-mirror-dir)", name)
+		}
+		return NewMirrorSource(opts.MirrorDir), nil
+	}
+	return nil, fmt.Errorf("unknown source %q (expected cache, scrape, local or mirror)", name)
+}
+
+// Chain tries its sources in priority order, falling back per question
+type Chain struct {
+	Sources []Source
+}
+
+// Builds a chain from a comma separated priority list such as "cache,scrape"
+func NewChain(names string, opts SourceOptions) (*Chain, error) {
+	chain := &Chain{}
+	for _, name := range strings.Split(names, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		source, err := NewSource(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		chain.Sources = append(chain.Sources, source)
+	}
+
+	if len(chain.Sources) == 0 {
+		return nil, errors.New("no sources configured")
+	}
+	return chain, nil
+}
+
+func (c *Chain) Name() string {
+	var names []string
+	for _, source := range c.Sources {
+		names = append(names, source.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Returns the exams of the first source able to list any
+func (c *Chain) ListExams(providerName string) ([]string, error) {
+	var lastErr error
+	for _, source := range c.Sources {
+		exams, err := source.ListExams(providerName)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(exams) > 0 {
+			return exams, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// Listing is the question links one source listed
+type Listing struct {
+	Source string
+	Links  []string
+}
+
+// Lists the questions of every source, skipping the ones that fail or list nothing
+func (c *Chain) Listings(providerName, grepStr string) ([]Listing, error) {
+	var lastErr error
+	var listings []Listing
+	for _, source := range c.Sources {
+		links, err := source.ListQuestions(providerName, grepStr)
+		if err != nil {
+			log.Printf("source %s failed to list questions: %v", source.Name(), err)
+			lastErr = err
+			continue
+		}
+		if len(links) > 0 {
+			fmt.Printf("Listed %d questions from source '%s'\n", len(links), source.Name())
+			listings = append(listings, Listing{Source: source.Name(), Links: links})
+		}
+	}
+
+	if len(listings) == 0 {
+		return nil, lastErr
+	}
+	return listings, nil
+}
+
+// Returns the question links of the first source able to list any, so a later source
+// such as the scraper is only asked when the earlier ones list nothing
+func (c *Chain) ListQuestions(providerName, grepStr string) ([]string, error) {
+	var lastErr error
+	for _, source := range c.Sources {
+		links, err := source.ListQuestions(providerName, grepStr)
+		if err != nil {
+			log.Printf("source %s failed to list questions: %v", source.Name(), err)
+			lastErr = err
+			continue
+		}
+		if len(links) > 0 {
+			fmt.Printf("Listed %d questions from source '%s'\n", len(links), source.Name())
+			return links, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// Joins listings in priority order, dropping repeated links. Links added by later
+// listings are sorted in by topic and question number
+func MergeListings(listings []Listing) []string {
+	var all []string
+	seen := make(map[string]struct{})
+	for _, listing := range listings {
+		for _, link := range listing.Links {
+			key := utils.NormalizeQuestionURL(link)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			all = append(all, link)
+		}
+	}
+
+	// A single listing keeps its source's order
+	if len(listings) > 1 {
+		sort.SliceStable(all, func(i, j int) bool {
+			return questionLess(all[i], all[j])
+		})
+	}
+	return all
+}
+
+// Orders links by topic and question number, keeping unparseable links at the end
+func questionLess(a, b string) bool {
+	refA, okA := utils.ParseQuestionLink(a)
+	refB, okB := utils.ParseQuestionLink(b)
+	if !okA || !okB {
+		return okA && !okB
+	}
+	if refA.Topic != refB.Topic {
+		return refA.Topic < refB.Topic
+	}
+	return refA.Number < refB.Number
+}
+
+// Fetches a question from the first source that has it
+func (c *Chain) FetchQuestion(link string) (*models.QuestionData, error) {
+	lastErr := ErrQuestionNotFound
+	for i, source := range c.Sources {
+		data, err := source.FetchQuestion(link)
+		if err == nil && data != nil {
+			if i > 0 {
+				log.Printf("fetched %s from fallback source %s", link, source.Name())
+			}
+			return data, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("no source could fetch %s: %w", link, lastErr)
+}
+
+// Lists every matching question and fetches them concurrently through the chain
+func (c *Chain) FetchAll(providerName, grepStr string) []models.QuestionData {
+	links, err := c.ListQuestions(providerName, grepStr)
+	if err != nil {
+		log.Printf("failed to list questions: %v", err)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	return utils.FilterOutNilData(c.FetchLinks(links, true))
+}
+
+// Fetches the given links concurrently, leaving nil entries for the ones no source has
+func (c *Chain) FetchLinks(links []string, logErrors bool) []*models.QuestionData {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, constants.MaxConcurrentRequests)
+	results := make([]*models.QuestionData, len(links))
+	startTime := utils.StartTime()
+	bar := pb.StartNew(len(links))
+
+	for i, link := range links {
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			data, err := c.FetchQuestion(link)
+			if err != nil && logErrors {
+				log.Print(err)
+			}
+			results[i] = data
+			bar.Increment()
+		}(i, link)
+	}
+
+	wg.Wait()
+	bar.Finish()
+	fmt.Printf("Fetching completed in %s.\n", utils.TimeSince(startTime))
+
+	return results
+}
+
+// questionIndex keeps questions keyed by their normalized link
+type questionIndex struct {
+	mu        sync.Mutex
+	questions map[string]*models.QuestionData
+	loaded    map[string][]string
+}
+
+func newQuestionIndex() *questionIndex {
+	return &questionIndex{
+		questions: make(map[string]*models.QuestionData),
+		loaded:    make(map[string][]string),
+	}
+}
+
+// Runs load once per key, indexes what it returns and remembers the links it produced.
+// A failed load is not remembered, so the next call retries it
+func (idx *questionIndex) ensure(key string, load func() ([]*models.QuestionData, error)) ([]string, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if links, ok := idx.loaded[key]; ok {
+		return links, nil
+	}
+
+	loaded, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	for _, data := range loaded {
+		if data == nil || data.QuestionLink == "" {
+			continue
+		}
+		links = append(links, utils.NormalizeQuestionURL(data.QuestionLink))
+		idx.questions[links[len(links)-1]] = data
+	}
+	links = utils.DeduplicateLinks(links)
+	idx.loaded[key] = links
+	return links, nil
+}
+
+func (idx *questionIndex) get(link string) (*models.QuestionData, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	data, ok := idx.questions[utils.NormalizeQuestionURL(link)]
+	return data, ok
+}
+
+// Returns a chain without the named sources, e.g. to avoid scraping
+func (c *Chain) Without(names ...string) *Chain {
+	filtered := &Chain{}
+	for _, source := range c.Sources {
+		skip := false
+		for _, name := range names {
+			if source.Name() == name {
+				skip = true
+			}
+		}
+		if !skip {
+			filtered.Sources = append(filtered.Sources, source)
+		}
+	}
+	return filtered
+}
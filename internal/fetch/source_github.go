@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"sort"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// GitHubSource serves questions from the cached examtopics-data repository
+type GitHubSource struct {
+	index *questionIndex
+}
+
+func NewGitHubSource() *GitHubSource {
+	return &GitHubSource{index: newQuestionIndex()}
+}
+
+func (s *GitHubSource) Name() string {
+	return "cache"
+}
+
+func (s *GitHubSource) ListExams(providerName string) ([]string, error) {
+	links, err := FetchCachedLinks(providerName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var exams []string
+	for _, link := range links {
+		exams = append(exams, utils.ExamNameFromCachedFile(link))
+	}
+	exams = utils.DeduplicateLinks(exams)
+	sort.Strings(exams)
+	return exams, nil
+}
+
+func (s *GitHubSource) ListQuestions(providerName, grepStr string) ([]string, error) {
+	return s.load(providerName, grepStr)
+}
+
+func (s *GitHubSource) FetchQuestion(link string) (*models.QuestionData, error) {
+	if data, ok := s.index.get(link); ok {
+		return data, nil
+	}
+
+	// Load the cached pages of the question's exam on first use
+	ref, ok := utils.ParseQuestionLink(link)
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+	if _, err := s.load(ref.Provider, ref.Exam); err != nil {
+		return nil, err
+	}
+
+	if data, ok := s.index.get(link); ok {
+		return data, nil
+	}
+	return nil, ErrQuestionNotFound
+}
+
+func (s *GitHubSource) load(providerName, grepStr string) ([]string, error) {
+	return s.index.ensure(providerName+"|"+grepStr, func() ([]*models.QuestionData, error) {
+		pages, err := GetCachedPages(providerName, grepStr)
+		if err != nil {
+			return nil, err
+		}
+
+		var results []*models.QuestionData
+		for _, data := range pages {
+			results = append(results, &data)
+		}
+		return results, nil
+	})
+}
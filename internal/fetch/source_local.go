@@ -0,0 +1,128 @@
+package fetch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// LocalSource serves questions from a local checkout of the cached JSON data,
+// laid out as <dir>/<Provider>/<exam>_<page>.json
+type LocalSource struct {
+	dir   string
+	index *questionIndex
+}
+
+func NewLocalSource(dir string) *LocalSource {
+	return &LocalSource{dir: dir, index: newQuestionIndex()}
+}
+
+func (s *LocalSource) Name() string {
+	return "local"
+}
+
+func (s *LocalSource) ListExams(providerName string) ([]string, error) {
+	files, err := s.files(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var exams []string
+	for _, file := range files {
+		exams = append(exams, utils.ExamNameFromCachedFile(file))
+	}
+	exams = utils.DeduplicateLinks(exams)
+	sort.Strings(exams)
+	return exams, nil
+}
+
+func (s *LocalSource) ListQuestions(providerName, grepStr string) ([]string, error) {
+	files, err := s.files(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(providerName, grepStr, files)
+}
+
+func (s *LocalSource) FetchQuestion(link string) (*models.QuestionData, error) {
+	if data, ok := s.index.get(link); ok {
+		return data, nil
+	}
+
+	ref, ok := utils.ParseQuestionLink(link)
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+	files, err := s.files(ref.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.load(ref.Provider, ref.Exam, files); err != nil {
+		return nil, err
+	}
+
+	if data, ok := s.index.get(link); ok {
+		return data, nil
+	}
+	return nil, ErrQuestionNotFound
+}
+
+func (s *LocalSource) load(providerName, grepStr string, files []string) ([]string, error) {
+	return s.index.ensure(providerName+"|"+grepStr, func() ([]*models.QuestionData, error) {
+		var matching []models.FileInfo
+		for _, file := range files {
+			if utils.MatchesCachedExam(filepath.Base(file), grepStr) {
+				matching = append(matching, models.FileInfo{
+					URL:    file,
+					Name:   filepath.Base(file),
+					Number: utils.ExtractNumberFromPath(filepath.Base(file)),
+				})
+			}
+		}
+
+		var results []*models.QuestionData
+		for _, file := range utils.SortCachedLinks(matching) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			for _, question := range parseQuestionsJSON(data, file) {
+				question.Origin = s.Name()
+				results = append(results, question)
+			}
+		}
+		return results, nil
+	})
+}
+
+// Returns the JSON files for a provider, preferring a matching provider directory
+func (s *LocalSource) files(providerName string) ([]string, error) {
+	root := s.dir
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), providerName) {
+			root = filepath.Join(s.dir, entry.Name())
+			break
+		}
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,147 @@
+package fetch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MirrorSource serves questions from a directory of saved discussion pages,
+// such as the output of wget --mirror or "Save page as" in a browser
+type MirrorSource struct {
+	dir   string
+	once  sync.Once
+	pages map[string]string // normalized link -> file path
+	err   error
+}
+
+func NewMirrorSource(dir string) *MirrorSource {
+	return &MirrorSource{dir: dir}
+}
+
+func (s *MirrorSource) Name() string {
+	return "mirror"
+}
+
+func (s *MirrorSource) ListExams(providerName string) ([]string, error) {
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	var exams []string
+	for link := range s.pages {
+		ref, ok := utils.ParseQuestionLink(link)
+		if ok && strings.EqualFold(ref.Provider, providerName) {
+			exams = append(exams, ref.Exam)
+		}
+	}
+	exams = utils.DeduplicateLinks(exams)
+	sort.Strings(exams)
+	return exams, nil
+}
+
+func (s *MirrorSource) ListQuestions(providerName, grepStr string) ([]string, error) {
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	var links []string
+	for link := range s.pages {
+		ref, ok := utils.ParseQuestionLink(link)
+		if ok && !strings.EqualFold(ref.Provider, providerName) {
+			continue
+		}
+		if utils.MatchesExam(link, grepStr) {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+	return utils.SortLinksByQuestionNumber(links), nil
+}
+
+func (s *MirrorSource) FetchQuestion(link string) (*models.QuestionData, error) {
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	normalized := utils.NormalizeQuestionURL(link)
+	path, ok := s.pages[normalized]
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+
+	doc, err := readHTMLFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsePage(doc, normalized)
+	if err != nil {
+		return nil, err
+	}
+	data.Origin = s.Name()
+	return data, nil
+}
+
+// Indexes every saved page by the discussion link it was saved from
+func (s *MirrorSource) scan() error {
+	s.once.Do(func() {
+		s.pages = make(map[string]string)
+		s.err = filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if d.IsDir() || (ext != ".html" && ext != ".htm") {
+				return nil
+			}
+
+			link := mirrorPageLink(path)
+			if link != "" {
+				s.pages[utils.NormalizeQuestionURL(link)] = path
+			}
+			return nil
+		})
+	})
+	return s.err
+}
+
+// Works out the original link of a saved page from its path, or failing that its canonical link
+func mirrorPageLink(path string) string {
+	slashed := filepath.ToSlash(path)
+	if _, ok := utils.ParseQuestionLink(slashed); ok {
+		idx := strings.Index(slashed, "/discussions/")
+		link := strings.TrimSuffix(slashed[idx:], "/index.html")
+		return strings.TrimSuffix(strings.TrimSuffix(link, ".html"), ".htm")
+	}
+
+	doc, err := readHTMLFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, selector := range []string{`link[rel="canonical"]`, `meta[property="og:url"]`} {
+		sel := doc.Find(selector).First()
+		link := sel.AttrOr("href", sel.AttrOr("content", ""))
+		if _, ok := utils.ParseQuestionLink(link); ok {
+			return link
+		}
+	}
+	return ""
+}
+
+func readHTMLFile(path string) (*goquery.Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return goquery.NewDocumentFromReader(file)
+}
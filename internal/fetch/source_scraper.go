@@ -0,0 +1,55 @@
+package fetch
+
+import (
+	"fmt"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// ScraperSource scrapes questions from the live examtopics website
+type ScraperSource struct{}
+
+func NewScraperSource() *ScraperSource {
+	return &ScraperSource{}
+}
+
+func (s *ScraperSource) Name() string {
+	return "scrape"
+}
+
+func (s *ScraperSource) ListExams(providerName string) ([]string, error) {
+	exams, err := ListProviderExams(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var slugs []string
+	for _, exam := range exams {
+		slugs = append(slugs, utils.ExamSlugFromLink(exam))
+	}
+	return slugs, nil
+}
+
+func (s *ScraperSource) ListQuestions(providerName, grepStr string) ([]string, error) {
+	discussionLinks, err := getDiscussionLinks(providerName, grepStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	for _, link := range discussionLinks {
+		links = append(links, utils.AddToBaseUrl(link))
+	}
+	return links, nil
+}
+
+func (s *ScraperSource) FetchQuestion(link string) (*models.QuestionData, error) {
+	<-rateLimiter.C
+
+	data, err := getDataFromLink(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape %s: %w", link, err)
+	}
+	return data, nil
+}
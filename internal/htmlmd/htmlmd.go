@@ -0,0 +1,369 @@
+package htmlmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Elements that are site chrome or scripting rather than question content
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true, "svg": true,
+	"form": true, "button": true, "input": true, "select": true, "textarea": true, "nav": true, "footer": true,
+}
+
+// Site chrome that shows up as plain text inside the question markup
+var chromePhrases = []string{"Forgot my password"}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true, "aside": true,
+	"center": true, "figure": true, "figcaption": true, "dl": true, "dt": true, "dd": true, "li": true,
+	"ul": true, "ol": true, "pre": true, "table": true, "blockquote": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+var (
+	spaceRe = regexp.MustCompile(`[\s\x{00a0}]+`)
+	// Whitespace of the HTML source, which leaves &nbsp; alone so cleanInline can read indentation
+	sourceSpaceRe = regexp.MustCompile(`\s+`)
+	headingRe     = regexp.MustCompile(`^#{1,6}(\s|$)`)
+)
+
+type converter struct {
+	base *url.URL
+}
+
+// Converts the matched elements to markdown, keeping code blocks, line breaks, tables,
+// lists and images. Relative image links are resolved against base
+func Convert(sel *goquery.Selection, base string) string {
+	c := converter{}
+	if u, err := url.Parse(base); err == nil && u.IsAbs() {
+		c.base = u
+	}
+
+	var blocks []string
+	for _, node := range sel.Nodes {
+		if node.Type == html.ElementNode && skippedElements[node.Data] {
+			continue
+		}
+		blocks = append(blocks, c.children(node)...)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Converts an HTML fragment, such as a question_text field, to markdown
+func FromString(raw, base string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(raw))
+	if err != nil {
+		return strings.TrimSpace(raw)
+	}
+	return Convert(doc.Find("body"), base)
+}
+
+// Converts the children of n into markdown blocks, grouping inline content into paragraphs
+func (c converter) children(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := cleanInline(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && blockElements[child.Data] {
+			flush()
+			if block := c.block(child); block != "" {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+		inline.WriteString(c.inline(child))
+	}
+	flush()
+	return blocks
+}
+
+func (c converter) block(n *html.Node) string {
+	switch n.Data {
+	case "pre":
+		return codeBlock(n)
+	case "ul", "ol":
+		return c.list(n)
+	case "table":
+		return c.table(n)
+	case "blockquote":
+		text := strings.Join(c.children(n), "\n\n")
+		return prefixLines(text, "> ", ">")
+	case "hr":
+		return "---"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		// Headings become bold text, since "## " lines start a new question in the markdown export
+		if text := strings.ReplaceAll(cleanInline(c.inlineChildren(n)), "\n", " "); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	}
+	return strings.Join(c.children(n), "\n\n")
+}
+
+func (c converter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return removeChrome(sourceSpaceRe.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedElements[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\n"
+	case "img":
+		return c.image(n)
+	case "code", "kbd", "tt":
+		if text := strings.TrimSpace(textContent(n)); text != "" {
+			return "`" + strings.ReplaceAll(text, "`", "'") + "`"
+		}
+		return ""
+	case "strong", "b":
+		return wrap(c.inlineChildren(n), "**")
+	case "em", "i":
+		return wrap(c.inlineChildren(n), "_")
+	}
+	if blockElements[n.Data] {
+		// A block nested in inline markup still gets its own lines
+		return "\n" + c.block(n) + "\n"
+	}
+	return c.inlineChildren(n)
+}
+
+func (c converter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+func (c converter) image(n *html.Node) string {
+	src := attr(n, "src")
+	if src == "" || strings.HasPrefix(src, "data:") {
+		src = attr(n, "data-src")
+	}
+	if src == "" {
+		return ""
+	}
+	if ref, err := url.Parse(src); err == nil && c.base != nil {
+		src = c.base.ResolveReference(ref).String()
+	}
+	alt := strings.TrimSpace(spaceRe.ReplaceAllString(attr(n, "alt"), " "))
+	return fmt.Sprintf("\n![%s](%s)\n", alt, strings.ReplaceAll(src, " ", "%20"))
+}
+
+func (c converter) list(n *html.Node) string {
+	var items []string
+	number := 1
+	if start := attr(n, "start"); start != "" {
+		fmt.Sscanf(start, "%d", &number)
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.Data == "ul" || child.Data == "ol" {
+			// Lists nested directly in a list, without a wrapping <li>
+			items = append(items, prefixLines(c.list(child), "  ", ""))
+			continue
+		}
+
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		text := strings.Join(c.children(child), "\n")
+		if child.Data != "li" && !blockElements[child.Data] {
+			text = cleanInline(c.inline(child))
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.TrimPrefix(prefixLines(text, indent, ""), indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (c converter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := strings.Join(c.children(cell), " ")
+						text = strings.ReplaceAll(strings.ReplaceAll(text, "\n", " "), "|", `\|`)
+						row = append(row, text)
+					}
+				}
+				rows = append(rows, row)
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Keeps the text of a <pre> block as is, in a fenced code block
+func codeBlock(n *html.Node) string {
+	language := ""
+	for node := n; node != nil; node = node.FirstChild {
+		for _, class := range strings.Fields(attr(node, "class")) {
+			if strings.HasPrefix(class, "language-") {
+				language = strings.TrimPrefix(class, "language-")
+			}
+		}
+	}
+
+	text := strings.Trim(textContent(n), "\n")
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + text + "\n" + fence
+}
+
+// Tidies a paragraph: collapses spaces, trims every line and keeps at most one blank line in a row.
+// Lines after a <br> keep their &nbsp; indentation, which the site uses to indent code
+func cleanInline(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		indent := ""
+		if len(lines) > 0 && !blank {
+			indent = nbspIndent(line)
+		}
+		line = strings.TrimSpace(spaceRe.ReplaceAllString(line, " "))
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		// Text that reads as a heading is escaped, since "## " lines start a new question
+		// in the markdown export
+		if indent == "" && headingRe.MatchString(line) {
+			line = `\` + line
+		}
+		lines = append(lines, indent+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns a space for every &nbsp; in the leading whitespace of a line
+func nbspIndent(line string) string {
+	count := 0
+	for _, r := range line {
+		switch r {
+		case '\u00a0':
+			count++
+		case ' ', '\t':
+		default:
+			return strings.Repeat(" ", count)
+		}
+	}
+	return ""
+}
+
+func removeChrome(text string) string {
+	for _, phrase := range chromePhrases {
+		text = strings.ReplaceAll(text, phrase, "")
+	}
+	return text
+}
+
+func wrap(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.Contains(trimmed, "\n") {
+		return text
+	}
+	// Keep the surrounding spaces outside the markers, so they still separate words
+	lead := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trail := text[len(strings.TrimRight(text, " ")):]
+	return lead + marker + trimmed + marker + trail
+}
+
+func prefixLines(text, prefix, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "br" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry describes one exam to export
+type Entry struct {
+	Provider string `json:"provider" yaml:"provider" toml:"provider"`
+	Search   string `json:"search" yaml:"search" toml:"search"`
+	Output   string `json:"output" yaml:"output" toml:"output"`
+	Format   string `json:"format" yaml:"format" toml:"format"`
+	Comments bool   `json:"comments" yaml:"comments" toml:"comments"`
+
+	CollapseDuplicates bool   `json:"collapse_duplicates" yaml:"collapse_duplicates" toml:"collapse_duplicates"`
+	Template           string `json:"template" yaml:"template" toml:"template"`
+	AnswerKey          bool   `json:"answer_key" yaml:"answer_key" toml:"answer_key"`
+	Split              string `json:"split" yaml:"split" toml:"split"`
+	TOC                bool   `json:"toc" yaml:"toc" toml:"toc"`
+	TOCStyle           string `json:"toc_style" yaml:"toc_style" toml:"toc_style"`
+}
+
+type Manifest struct {
+	Exams []Entry `json:"exams" yaml:"exams" toml:"exams"`
+}
+
+type RunOptions struct {
+	NoCache bool
+}
+
+// Loads a YAML, JSON or TOML manifest, picked by file extension
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &m)
+	case ".json":
+		err = json.Unmarshal(data, &m)
+	case ".toml":
+		err = toml.Unmarshal(data, &m)
+	default:
+		return nil, fmt.Errorf("unsupported manifest extension %q (expected .yaml, .json or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	for i, entry := range m.Exams {
+		if entry.Provider == "" || entry.Search == "" || entry.Output == "" {
+			return nil, fmt.Errorf("manifest entry %d needs provider, search and output", i+1)
+		}
+	}
+	return &m, nil
+}
+
+// Exports every entry, crawling the discussion pages of each provider at most once
+func Run(m *Manifest, opts RunOptions) error {
+	var providers []string
+	byProvider := make(map[string][]Entry)
+	for _, entry := range m.Exams {
+		provider := strings.ToLower(entry.Provider)
+		if _, ok := byProvider[provider]; !ok {
+			providers = append(providers, provider)
+		}
+		byProvider[provider] = append(byProvider[provider], entry)
+	}
+
+	var failed int
+	for _, provider := range providers {
+		failed += runProvider(provider, byProvider[provider], opts)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifest entries failed", failed, len(m.Exams))
+	}
+	return nil
+}
+
+// Exports the entries of one provider, listing its exams and cached data files only once
+func runProvider(provider string, entries []Entry, opts RunOptions) int {
+	var examSlugs []string
+	exams, err := fetch.ListProviderExamInfo(provider)
+	if err != nil {
+		log.Printf("could not list the exams of provider '%s', using the searches as exam codes: %v", provider, err)
+	}
+	for _, exam := range exams {
+		examSlugs = append(examSlugs, exam.Slug)
+	}
+
+	var cachedLinks []string
+	if !opts.NoCache {
+		cachedLinks, err = fetch.FetchCachedLinks(provider, "")
+		if err != nil {
+			log.Printf("could not list the cached data of provider '%s', scraping instead: %v", provider, err)
+		}
+	}
+
+	var failed int
+	slugs := make(map[int]string)
+	var toScrape []int
+
+	for i, entry := range entries {
+		slug, err := resolveEntry(provider, entry, examSlugs)
+		if err != nil {
+			log.Printf("skipping %s: %v", entry.Output, err)
+			failed++
+			continue
+		}
+		slugs[i] = slug
+
+		var examLinks []string
+		for _, link := range cachedLinks {
+			if utils.MatchesCachedExam(link, slug) {
+				examLinks = append(examLinks, link)
+			}
+		}
+		if len(examLinks) > 0 {
+			if cached := fetch.GetCachedPagesFromLinks(examLinks); len(cached) > 0 {
+				failed += write(entry, cached)
+				continue
+			}
+		}
+		toScrape = append(toScrape, i)
+	}
+
+	if len(toScrape) == 0 {
+		return failed
+	}
+
+	var scrapeSlugs []string
+	for _, i := range toScrape {
+		scrapeSlugs = append(scrapeSlugs, slugs[i])
+	}
+
+	fmt.Printf("Scraping %d exams for provider '%s' from one crawl\n", len(scrapeSlugs), provider)
+	scraped, err := fetch.GetAllPagesForExams(provider, utils.DeduplicateLinks(scrapeSlugs))
+	if err != nil {
+		log.Printf("skipping %d exams of provider '%s': %v", len(toScrape), provider, err)
+		return failed + len(toScrape)
+	}
+	for _, i := range toScrape {
+		failed += write(entries[i], scraped[slugs[i]])
+	}
+	return failed
+}
+
+// Resolves an entry's search against the provider's exams. An unknown exam is used as
+// given, since the provider page may not list every exam
+func resolveEntry(provider string, entry Entry, examSlugs []string) (string, error) {
+	if len(examSlugs) == 0 {
+		return entry.Search, nil
+	}
+
+	slug, err := fetch.MatchExam(provider, entry.Search, examSlugs)
+	var matchErr *fetch.ExamMatchError
+	if errors.As(err, &matchErr) && !matchErr.Ambiguous {
+		log.Printf("%v, using it as the exam code for %s", err, entry.Output)
+		return entry.Search, nil
+	}
+	return slug, err
+}
+
+func write(entry Entry, data []models.QuestionData) int {
+	if len(data) == 0 {
+		log.Printf("no questions found for %s (%s)", entry.Output, entry.Search)
+		return 1
+	}
+
+	if entry.CollapseDuplicates {
+		var groups int
+		data, groups = export.CollapseDuplicates(data)
+		fmt.Printf("Collapsed %d groups of near-duplicate questions in %s\n", groups, entry.Output)
+	}
+
+	err := export.Write(data, entry.Output, export.Options{
+		Format:    entry.Format,
+		Comments:  entry.Comments,
+		Template:  entry.Template,
+		AnswerKey: entry.AnswerKey,
+		Split:     entry.Split,
+		TOC:       entry.TOC,
+		TOCStyle:  entry.TOCStyle,
+	})
+	if err != nil {
+		log.Printf("failed to write %s: %v", entry.Output, err)
+		return 1
+	}
+
+	fmt.Printf("Successfully saved %d questions to %s.\n", len(data), entry.Output)
+	return 0
+}
@@ -1,14 +1,33 @@
 package models
 
 type QuestionData struct {
-	Title        string
-	Header       string
-	Content      string
-	Questions    []string
-	Answer       string
-	Timestamp    string
-	QuestionLink string
-	Comments     string
+	Title        string    `json:"title"`
+	Header       string    `json:"header"`
+	Content      string    `json:"content,omitempty"`
+	Questions    []string  `json:"questions"`
+	Answer       string    `json:"answer"`
+	Timestamp    string    `json:"timestamp"`
+	QuestionLink string    `json:"question_link"`
+	Comments     string    `json:"comments,omitempty"`
+	Discussion   []Comment `json:"discussion,omitempty"`
+	Votes        []Vote    `json:"votes,omitempty"`
+	Duplicates   []string  `json:"duplicates,omitempty"`
+	Origin       string    `json:"origin,omitempty"`
+	Page         int       `json:"page,omitempty"`
+}
+
+type Comment struct {
+	Poster         string `json:"poster"`
+	Content        string `json:"content"`
+	Upvotes        int    `json:"upvotes"`
+	Timestamp      string `json:"timestamp,omitempty"`
+	SelectedAnswer string `json:"selected_answer,omitempty"`
+}
+
+type Vote struct {
+	Answer    string `json:"answer"`
+	Count     int    `json:"count"`
+	MostVoted bool   `json:"most_voted,omitempty"`
 }
 
 type FileInfo struct {
@@ -18,27 +37,61 @@ type FileInfo struct {
 }
 
 type JSONResponse struct {
-	PageProps struct {
-		Questions []struct {
-			Choices           map[string]string `json:"choices"`
-			ID                string            `json:"id"`
-			ExamID            int               `json:"exam_id"`
-			QuestionText      string            `json:"question_text"`
-			Answer            string            `json:"answer"`
-			AnswerET          string            `json:"answer_ET"`
-			Topic             string            `json:"topic"`
-			IsMC              bool              `json:"isMC"`
-			AnswerDescription string            `json:"answer_description"`
-			Discussion        []struct {
-				Content     string `json:"content"`
-				UpvoteCount string `json:"upvote_count"`
-				Poster      string `json:"poster"`
-				Timestamp   string `json:"timestamp"`
-			} `json:"discussion"`
-			AnswerImages   []string `json:"answer_images"`
-			QuestionImages []string `json:"question_images"`
-			URL            string   `json:"url"`
-			Timestamp      string   `json:"timestamp"`
-		} `json:"questions"`
-	} `json:"pageProps"`
+	PageProps JSONPageProps `json:"pageProps"`
+}
+
+// JSONPageProps is the page data of the site, as cached on GitHub or embedded in live pages
+type JSONPageProps struct {
+	Questions []JSONQuestion `json:"questions"`
+	Question  *JSONQuestion  `json:"question,omitempty"`
+}
+
+type JSONQuestion struct {
+	Choices           map[string]string `json:"choices"`
+	ID                string            `json:"id"`
+	ExamID            int               `json:"exam_id"`
+	QuestionText      string            `json:"question_text"`
+	Answer            string            `json:"answer"`
+	AnswerET          string            `json:"answer_ET"`
+	Topic             string            `json:"topic"`
+	IsMC              bool              `json:"isMC"`
+	AnswerDescription string            `json:"answer_description"`
+	Discussion        []struct {
+		Content     string `json:"content"`
+		UpvoteCount string `json:"upvote_count"`
+		Poster      string `json:"poster"`
+		Timestamp   string `json:"timestamp"`
+	} `json:"discussion"`
+	AnswerImages   []string `json:"answer_images"`
+	QuestionImages []string `json:"question_images"`
+	URL            string   `json:"url"`
+	Timestamp      string   `json:"timestamp"`
+}
+
+// NextData is the payload Next.js embeds in live pages as <script id="__NEXT_DATA__">
+type NextData struct {
+	Props struct {
+		PageProps JSONPageProps `json:"pageProps"`
+	} `json:"props"`
+}
+
+type QuestionRef struct {
+	Provider string
+	ID       int
+	Exam     string
+	Topic    int
+	Number   int
+}
+
+type ExamInfo struct {
+	Provider      string `json:"provider"`
+	Slug          string `json:"slug"`
+	Name          string `json:"name"`
+	URL           string `json:"url"`
+	QuestionCount int    `json:"question_count,omitempty"`
+}
+
+type Choice struct {
+	Letter string `json:"letter"`
+	Text   string `json:"text"`
 }
@@ -0,0 +1,164 @@
+package quiz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+type Filter struct {
+	Topic  int   // only questions of this topic, 0 for all
+	From   int   // lowest question number, 0 for no limit
+	To     int   // highest question number, 0 for no limit
+	Sample int   // pick this many random questions, 0 for all
+	Seed   int64 // seed for sampling, so a mock exam can be repeated
+}
+
+// Applies the topic and range filters, then samples when asked
+func Select(questions []models.QuestionData, filter Filter) []models.QuestionData {
+	var selected []models.QuestionData
+	for _, question := range questions {
+		ref, ok := utils.ParseQuestionLink(question.QuestionLink)
+		if filter.Topic > 0 && (!ok || ref.Topic != filter.Topic) {
+			continue
+		}
+		if filter.From > 0 && (!ok || ref.Number < filter.From) {
+			continue
+		}
+		if filter.To > 0 && (!ok || ref.Number > filter.To) {
+			continue
+		}
+		selected = append(selected, question)
+	}
+
+	if filter.Sample > 0 && filter.Sample < len(selected) {
+		rng := rand.New(rand.NewSource(filter.Seed))
+		rng.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+		selected = selected[:filter.Sample]
+	}
+	return selected
+}
+
+// Outcome of asking a single question
+type Outcome int
+
+const (
+	Correct Outcome = iota
+	Wrong
+	Skipped
+	Quit
+)
+
+// Session reads answers from in and writes questions to out
+type Session struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func NewSession(in io.Reader, out io.Writer) *Session {
+	return &Session{in: bufio.NewReader(in), out: out}
+}
+
+// Shows a question, reads an answer and reveals the suggested and community answers
+func (s *Session) Ask(question models.QuestionData, position, total int) Outcome {
+	fmt.Fprintf(s.out, "\n[%d/%d] %s\n\n", position, total, question.Title)
+	fmt.Fprintf(s.out, "%s\n\n", utils.QuestionBody(question))
+	for _, image := range utils.QuestionImages(question) {
+		fmt.Fprintf(s.out, "Image: %s\n", image)
+	}
+	for _, choice := range utils.ParseChoices(question.Questions) {
+		fmt.Fprintf(s.out, "  %s. %s\n", choice.Letter, choice.Text)
+	}
+
+	fmt.Fprint(s.out, "\nYour answer (letters, s to skip, q to quit): ")
+	line, err := s.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		return Quit
+	}
+
+	switch strings.ToLower(line) {
+	case "q", "quit":
+		return Quit
+	case "s", "skip", "":
+		s.reveal(question)
+		return Skipped
+	}
+
+	given := utils.NormalizeAnswer(line)
+	expected := utils.NormalizeAnswer(question.Answer)
+	outcome := Wrong
+	if given == expected {
+		outcome = Correct
+		fmt.Fprintln(s.out, "Correct!")
+	} else {
+		fmt.Fprintf(s.out, "Wrong, you answered %s.\n", given)
+	}
+	s.reveal(question)
+	return outcome
+}
+
+func (s *Session) reveal(question models.QuestionData) {
+	fmt.Fprintf(s.out, "Suggested answer: %s\n", question.Answer)
+	if len(question.Votes) > 0 {
+		fmt.Fprintf(s.out, "Community answer: %s\n", utils.FormatVotes(question.Votes))
+	}
+}
+
+type Score struct {
+	Correct  int
+	Wrong    int
+	Skipped  int
+	Total    int
+	Duration time.Duration
+}
+
+func (s Score) Percent() float64 {
+	answered := s.Correct + s.Wrong + s.Skipped
+	if answered == 0 {
+		return 0
+	}
+	return float64(s.Correct) * 100 / float64(answered)
+}
+
+// Asks every question in order until they run out, the user quits or the time limit passes
+func (s *Session) Run(questions []models.QuestionData, timeLimit time.Duration) Score {
+	score := Score{Total: len(questions)}
+	start := time.Now()
+
+	for i, question := range questions {
+		if timeLimit > 0 && time.Since(start) > timeLimit {
+			fmt.Fprintln(s.out, "\nTime is up!")
+			break
+		}
+
+		switch s.Ask(question, i+1, len(questions)) {
+		case Correct:
+			score.Correct++
+		case Wrong:
+			score.Wrong++
+		case Skipped:
+			score.Skipped++
+		case Quit:
+			score.Duration = time.Since(start)
+			return score
+		}
+	}
+
+	score.Duration = time.Since(start)
+	return score
+}
+
+func (s Score) Print(w io.Writer) {
+	fmt.Fprintf(w, "\nScore: %d/%d correct (%.0f%%), %d wrong, %d skipped, %d not reached, in %s\n",
+		s.Correct, s.Correct+s.Wrong+s.Skipped, s.Percent(), s.Wrong, s.Skipped,
+		s.Total-s.Correct-s.Wrong-s.Skipped, s.Duration.Round(time.Second))
+}
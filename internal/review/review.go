@@ -0,0 +1,159 @@
+package review
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/quiz"
+)
+
+// Picks the questions to drill: due cards first, most overdue and hardest first,
+// then up to maxNew questions the user has never seen
+func Due(store *Store, user string, questions []models.QuestionData, now time.Time, maxReviews, maxNew int) []models.QuestionData {
+	type dueQuestion struct {
+		data models.QuestionData
+		card *Card
+	}
+
+	var due []dueQuestion
+	var fresh []models.QuestionData
+	for _, question := range questions {
+		card, ok := store.Lookup(user, question.QuestionLink)
+		switch {
+		case !ok:
+			if len(fresh) < maxNew {
+				fresh = append(fresh, question)
+			}
+		case card.IsDue(now):
+			due = append(due, dueQuestion{question, card})
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		if !due[i].card.Due.Equal(due[j].card.Due) {
+			return due[i].card.Due.Before(due[j].card.Due)
+		}
+		return due[i].card.Ease < due[j].card.Ease
+	})
+
+	var selected []models.QuestionData
+	for _, entry := range due {
+		if maxReviews > 0 && len(selected) >= maxReviews {
+			break
+		}
+		selected = append(selected, entry.data)
+	}
+	return append(selected, fresh...)
+}
+
+// Drills the questions, scheduling each answered one and saving after every answer
+func Drill(session *quiz.Session, store *Store, user string, questions []models.QuestionData, out io.Writer) (quiz.Score, error) {
+	score := quiz.Score{Total: len(questions)}
+	start := time.Now()
+
+	for i, question := range questions {
+		quality := 0
+		switch session.Ask(question, i+1, len(questions)) {
+		case quiz.Correct:
+			score.Correct++
+			quality = QualityCorrect
+		case quiz.Wrong:
+			score.Wrong++
+			quality = QualityWrong
+		case quiz.Skipped:
+			score.Skipped++
+			continue
+		case quiz.Quit:
+			score.Duration = time.Since(start)
+			return score, nil
+		}
+
+		card := store.Card(user, question.QuestionLink)
+		card.Review(quality, time.Now())
+		fmt.Fprintf(out, "Next review in %d day(s)\n", card.Interval)
+
+		if err := store.Save(); err != nil {
+			return score, err
+		}
+	}
+
+	score.Duration = time.Since(start)
+	return score, nil
+}
+
+type StatRow struct {
+	Exam     string
+	Topic    int
+	Seen     int
+	Due      int
+	Learned  int
+	Reviews  int
+	Lapses   int
+	Accuracy float64
+}
+
+// Summarizes a user's cards per exam and topic, with a total row per exam (topic 0)
+func Stats(store *Store, user string, now time.Time) []StatRow {
+	rows := make(map[[2]string]*StatRow)
+	add := func(exam string, topic int, card *Card) {
+		key := [2]string{exam, fmt.Sprint(topic)}
+		row, ok := rows[key]
+		if !ok {
+			row = &StatRow{Exam: exam, Topic: topic}
+			rows[key] = row
+		}
+		row.Seen++
+		row.Reviews += card.Reviews
+		row.Lapses += card.Lapses
+		if card.IsDue(now) {
+			row.Due++
+		}
+		if card.Learned() {
+			row.Learned++
+		}
+	}
+
+	for _, card := range store.Users[user] {
+		exam := card.Exam
+		if exam == "" {
+			exam = "unknown"
+		}
+		add(exam, 0, card)
+		if card.Topic > 0 {
+			add(exam, card.Topic, card)
+		}
+	}
+
+	var result []StatRow
+	for _, row := range rows {
+		if row.Reviews > 0 {
+			row.Accuracy = 1 - float64(row.Lapses)/float64(row.Reviews)
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Exam != result[j].Exam {
+			return result[i].Exam < result[j].Exam
+		}
+		return result[i].Topic < result[j].Topic
+	})
+	return result
+}
+
+func PrintStats(w io.Writer, rows []StatRow) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "EXAM\tTOPIC\tSEEN\tDUE\tLEARNED\tREVIEWS\tACCURACY")
+	for _, row := range rows {
+		topic := "all"
+		if row.Topic > 0 {
+			topic = fmt.Sprint(row.Topic)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%d\t%.0f%%\n",
+			row.Exam, topic, row.Seen, row.Due, row.Learned, row.Reviews, row.Accuracy*100)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,70 @@
+package review
+
+import (
+	"math"
+	"time"
+)
+
+// Card is the review state of one question for one user
+type Card struct {
+	Link        string    `json:"link"`
+	Exam        string    `json:"exam,omitempty"`
+	Topic       int       `json:"topic,omitempty"`
+	Ease        float64   `json:"ease"`
+	Interval    int       `json:"interval_days"`
+	Repetitions int       `json:"repetitions"`
+	Due         time.Time `json:"due"`
+	Reviews     int       `json:"reviews"`
+	Lapses      int       `json:"lapses"`
+	LastReview  time.Time `json:"last_review"`
+}
+
+const (
+	initialEase = 2.5
+	minEase     = 1.3
+)
+
+// Answer qualities on the SM-2 0-5 scale
+const (
+	QualityWrong   = 1
+	QualityCorrect = 4
+)
+
+func NewCard(link, exam string, topic int) *Card {
+	return &Card{Link: link, Exam: exam, Topic: topic, Ease: initialEase}
+}
+
+// Schedules the next review with the SM-2 algorithm
+func (c *Card) Review(quality int, now time.Time) {
+	c.Reviews++
+	c.LastReview = now
+
+	if quality < 3 {
+		c.Repetitions = 0
+		c.Interval = 1
+		c.Lapses++
+	} else {
+		switch c.Repetitions {
+		case 0:
+			c.Interval = 1
+		case 1:
+			c.Interval = 6
+		default:
+			c.Interval = int(math.Round(float64(c.Interval) * c.Ease))
+		}
+		c.Repetitions++
+	}
+
+	q := float64(quality)
+	c.Ease = math.Max(minEase, c.Ease+0.1-(5-q)*(0.08+(5-q)*0.02))
+	c.Due = now.AddDate(0, 0, c.Interval)
+}
+
+func (c *Card) IsDue(now time.Time) bool {
+	return !c.Due.After(now)
+}
+
+// A card counts as learned once its interval reaches three weeks
+func (c *Card) Learned() bool {
+	return c.Interval >= 21
+}
@@ -0,0 +1,98 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"examtopics-downloader/internal/utils"
+)
+
+// Store keeps every user's cards in one JSON file, keyed by normalized question link
+type Store struct {
+	path  string
+	Users map[string]map[string]*Card `json:"users"`
+}
+
+// Returns the default progress file in the user's config directory
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "examtopics-downloader", "progress.json")
+}
+
+func Open(path string) (*Store, error) {
+	store := &Store{path: path, Users: make(map[string]map[string]*Card)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	if store.Users == nil {
+		store.Users = make(map[string]map[string]*Card)
+	}
+	return store, nil
+}
+
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted save keeps the old progress
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Returns the user's card for a question, creating it when the question is new
+func (s *Store) Card(user, link string) *Card {
+	cards, ok := s.Users[user]
+	if !ok {
+		cards = make(map[string]*Card)
+		s.Users[user] = cards
+	}
+
+	key := utils.NormalizeQuestionURL(link)
+	card, ok := cards[key]
+	if !ok {
+		ref, _ := utils.ParseQuestionLink(link)
+		card = NewCard(key, ref.Exam, ref.Topic)
+		cards[key] = card
+	}
+	return card
+}
+
+// Looks up a card without creating it
+func (s *Store) Lookup(user, link string) (*Card, bool) {
+	card, ok := s.Users[user][utils.NormalizeQuestionURL(link)]
+	return card, ok
+}
+
+// Removes the user's cards, limited to one exam when exam is set. Returns how many were removed
+func (s *Store) Reset(user, exam string) int {
+	removed := 0
+	for key, card := range s.Users[user] {
+		if exam == "" || utils.MatchesExam(card.Link, exam) {
+			delete(s.Users[user], key)
+			removed++
+		}
+	}
+	return removed
+}
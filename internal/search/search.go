@@ -0,0 +1,380 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Fields of a question that are indexed, with the weight of a match in each
+type Field int
+
+const (
+	FieldQuestion Field = iota
+	FieldChoices
+	FieldComments
+	numFields
+)
+
+var fieldNames = [numFields]string{"question", "choices", "comments"}
+var fieldWeights = [numFields]float64{2.0, 1.5, 0.5}
+
+func (f Field) String() string {
+	return fieldNames[f]
+}
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Words ignored in queries, so natural questions like "which questions mention X and Y" work
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "with": true, "about": true, "is": true, "are": true, "that": true, "which": true,
+	"what": true, "question": true, "questions": true, "mention": true, "mentions": true, "mentioning": true,
+}
+
+type token struct {
+	term  string
+	start int // byte offsets in the field text, used for snippets
+	end   int
+}
+
+type document struct {
+	question models.QuestionData
+	texts    [numFields]string
+	tokens   [numFields][]token
+}
+
+// Index is an in-memory inverted index over questions
+type Index struct {
+	docs     []document
+	postings map[string]map[int][numFields]int // term -> doc -> term frequency per field
+	avgLen   [numFields]float64
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{term: stem(strings.ToLower(text[start:end])), start: start, end: end})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+		} else {
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+// A very light stemmer so "regions" matches "region"
+func stem(term string) string {
+	if len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") {
+		return term[:len(term)-1]
+	}
+	return term
+}
+
+func NewIndex(questions []models.QuestionData) *Index {
+	idx := &Index{postings: make(map[string]map[int][numFields]int)}
+	var totalLen [numFields]int
+
+	for id, question := range questions {
+		var choices []string
+		for _, choice := range utils.ParseChoices(question.Questions) {
+			choices = append(choices, choice.Letter+". "+choice.Text)
+		}
+		comments := question.Comments
+		if len(question.Discussion) > 0 {
+			var parts []string
+			for _, comment := range question.Discussion {
+				parts = append(parts, comment.Content)
+			}
+			comments = strings.Join(parts, " ")
+		}
+
+		doc := document{question: question}
+		doc.texts[FieldQuestion] = utils.CleanText(utils.QuestionBody(question))
+		doc.texts[FieldChoices] = strings.Join(choices, " ")
+		doc.texts[FieldComments] = utils.CleanText(comments)
+
+		for field := range numFields {
+			doc.tokens[field] = tokenize(doc.texts[field])
+			totalLen[field] += len(doc.tokens[field])
+			for _, tok := range doc.tokens[field] {
+				docs, ok := idx.postings[tok.term]
+				if !ok {
+					docs = make(map[int][numFields]int)
+					idx.postings[tok.term] = docs
+				}
+				freqs := docs[id]
+				freqs[field]++
+				docs[id] = freqs
+			}
+		}
+		idx.docs = append(idx.docs, doc)
+	}
+
+	for field := range numFields {
+		if len(idx.docs) > 0 {
+			idx.avgLen[field] = float64(totalLen[field]) / float64(len(idx.docs))
+		}
+	}
+	return idx
+}
+
+// Query is a parsed search: every term and phrase must occur somewhere in the question
+type Query struct {
+	Terms   []string
+	Phrases [][]string
+}
+
+// Parses a query. "Quoted text" and hyphenated words are phrases, stop words are dropped
+func ParseQuery(raw string) Query {
+	var q Query
+	parts := strings.Split(raw, `"`)
+	for i, part := range parts {
+		if i%2 == 1 {
+			if phrase := terms(part); len(phrase) > 0 {
+				q.Phrases = append(q.Phrases, phrase)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			words := terms(word)
+			switch {
+			case len(words) > 1:
+				q.Phrases = append(q.Phrases, words)
+			case len(words) == 1 && !stopWords[words[0]]:
+				q.Terms = append(q.Terms, words[0])
+			}
+		}
+	}
+	return q
+}
+
+func terms(text string) []string {
+	var result []string
+	for _, tok := range tokenize(text) {
+		result = append(result, tok.term)
+	}
+	return result
+}
+
+func (q Query) allTerms() []string {
+	all := append([]string{}, q.Terms...)
+	for _, phrase := range q.Phrases {
+		all = append(all, phrase...)
+	}
+	return all
+}
+
+type Result struct {
+	Question models.QuestionData
+	Score    float64
+	Field    Field
+	Snippet  string
+}
+
+// Searches the index, returning at most limit results ranked by BM25
+func (idx *Index) Search(q Query, limit int, highlight Highlight) []Result {
+	all := q.allTerms()
+	if len(all) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for id, doc := range idx.docs {
+		if !idx.matches(id, doc, q) {
+			continue
+		}
+
+		score := 0.0
+		var fieldScores [numFields]float64
+		for _, term := range all {
+			docs := idx.postings[term]
+			idf := math.Log(1 + (float64(len(idx.docs))-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+			freqs := docs[id]
+			for field := range numFields {
+				tf := float64(freqs[field])
+				if tf == 0 {
+					continue
+				}
+				norm := 1 - b + b*float64(len(doc.tokens[field]))/max(idx.avgLen[field], 1)
+				fieldScore := fieldWeights[field] * idf * tf * (k1 + 1) / (tf + k1*norm)
+				fieldScores[field] += fieldScore
+				score += fieldScore
+			}
+		}
+
+		best := FieldQuestion
+		for field := range numFields {
+			if fieldScores[field] > fieldScores[best] {
+				best = field
+			}
+		}
+		results = append(results, Result{
+			Question: doc.question,
+			Score:    score,
+			Field:    best,
+			Snippet:  snippet(doc.texts[best], doc.tokens[best], all, highlight),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Every term must appear in some field, and every phrase must appear in order within one field
+func (idx *Index) matches(id int, doc document, q Query) bool {
+	for _, term := range q.Terms {
+		if _, ok := idx.postings[term][id]; !ok {
+			return false
+		}
+	}
+	for _, phrase := range q.Phrases {
+		found := false
+		for field := range numFields {
+			if containsPhrase(doc.tokens[field], phrase) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPhrase(tokens []token, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		matched := true
+		for j, term := range phrase {
+			if tokens[i+j].term != term {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Highlight holds the markers put around matched words in snippets
+type Highlight struct {
+	Start string
+	End   string
+}
+
+var MarkdownHighlight = Highlight{Start: "**", End: "**"}
+var TerminalHighlight = Highlight{Start: "\033[1;33m", End: "\033[0m"}
+
+const snippetRadius = 80
+
+// Cuts a window of text around the densest cluster of matches and highlights them
+func snippet(text string, tokens []token, queryTerms []string, highlight Highlight) string {
+	wanted := make(map[string]bool, len(queryTerms))
+	for _, term := range queryTerms {
+		wanted[term] = true
+	}
+
+	var hits []token
+	for _, tok := range tokens {
+		if wanted[tok.term] {
+			hits = append(hits, tok)
+		}
+	}
+	if len(hits) == 0 {
+		return truncate(text, 2*snippetRadius)
+	}
+
+	// Center the window on the hit with the most other hits nearby
+	center, bestCount := hits[0].start, 0
+	for _, hit := range hits {
+		count := 0
+		for _, other := range hits {
+			if abs(other.start-hit.start) <= snippetRadius {
+				count++
+			}
+		}
+		if count > bestCount {
+			center, bestCount = hit.start, count
+		}
+	}
+
+	from := runeBoundary(text, max(center-snippetRadius, 0))
+	to := runeBoundary(text, min(center+snippetRadius, len(text)))
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("...")
+	}
+	// Merge hits only separated by a space or hyphen, so phrases are highlighted as one
+	var spans []token
+	for _, hit := range hits {
+		if hit.start < from || hit.end > to {
+			continue
+		}
+		if n := len(spans); n > 0 && (text[spans[n-1].end:hit.start] == " " || text[spans[n-1].end:hit.start] == "-") {
+			spans[n-1].end = hit.end
+			continue
+		}
+		spans = append(spans, hit)
+	}
+
+	pos := from
+	for _, span := range spans {
+		b.WriteString(text[pos:span.start])
+		b.WriteString(highlight.Start + text[span.start:span.end] + highlight.End)
+		pos = span.end
+	}
+	b.WriteString(text[pos:to])
+	if to < len(text) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+func runeBoundary(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	return text[:runeBoundary(text, n)] + "..."
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,256 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Question types, guessed from the question text and answer
+const (
+	TypeSingle   = "single answer"
+	TypeMultiple = "multiple answers"
+	TypeHotspot  = "hotspot"
+	TypeDragDrop = "drag and drop"
+	TypeOther    = "other"
+)
+
+// Topic summarises the questions of one topic of an exam
+type Topic struct {
+	Topic     int   `json:"topic"`
+	Questions int   `json:"questions"`
+	Highest   int   `json:"highest_number"`
+	Missing   []int `json:"missing_numbers,omitempty"`
+}
+
+// Summary describes an export, to judge whether it is complete
+type Summary struct {
+	Questions           int            `json:"questions"`
+	Topics              []Topic        `json:"topics"`
+	Unparsed            int            `json:"unparsed_links,omitempty"`
+	Types               map[string]int `json:"types"`
+	WithImages          int            `json:"with_images"`
+	WithAnswer          int            `json:"with_answer"`
+	Comments            int            `json:"comments"`
+	WithComments        int            `json:"with_comments"`
+	AnswerLetters       map[string]int `json:"answer_letters"`
+	Oldest              string         `json:"oldest,omitempty"`
+	Newest              string         `json:"newest,omitempty"`
+	UnparsedDates       int            `json:"unparsed_timestamps,omitempty"`
+	Origins             map[string]int `json:"origins"`
+	CommentsPerQuestion float64        `json:"comments_per_question"`
+}
+
+var (
+	hotspotRe  = regexp.MustCompile(`(?i)^\W*hotspot\b`)
+	dragDropRe = regexp.MustCompile(`(?i)^\W*drag\s*(and\s*)?drop\b`)
+)
+
+// Returns the type of a question
+func QuestionType(question models.QuestionData) string {
+	// Scraped headers carry the exam and question number, so only the text is matched
+	body := strings.TrimSpace(utils.QuestionBody(question))
+	switch {
+	case hotspotRe.MatchString(body):
+		return TypeHotspot
+	case dragDropRe.MatchString(body):
+		return TypeDragDrop
+	case len(utils.ParseChoices(question.Questions)) == 0:
+		return TypeOther
+	case len(utils.NormalizeAnswer(question.Answer)) > 1:
+		return TypeMultiple
+	}
+	return TypeSingle
+}
+
+// Summarises the questions of an export
+func Summarize(questions []models.QuestionData) Summary {
+	summary := Summary{
+		Questions:     len(questions),
+		Types:         make(map[string]int),
+		AnswerLetters: make(map[string]int),
+		Origins:       make(map[string]int),
+	}
+
+	numbers := make(map[int]map[int]struct{})
+	var oldest, newest time.Time
+	for _, question := range questions {
+		if ref, ok := utils.ParseQuestionLink(question.QuestionLink); ok {
+			if numbers[ref.Topic] == nil {
+				numbers[ref.Topic] = make(map[int]struct{})
+			}
+			numbers[ref.Topic][ref.Number] = struct{}{}
+		} else {
+			summary.Unparsed++
+		}
+
+		summary.Types[QuestionType(question)]++
+		if len(utils.QuestionImages(question)) > 0 {
+			summary.WithImages++
+		}
+
+		comments := len(question.Discussion)
+		if comments == 0 && strings.TrimSpace(question.Comments) != "" {
+			comments = 1
+		}
+		summary.Comments += comments
+		if comments > 0 {
+			summary.WithComments++
+		}
+
+		if answer := utils.NormalizeAnswer(question.Answer); answer != "" {
+			summary.WithAnswer++
+			for _, letter := range answer {
+				summary.AnswerLetters[string(letter)]++
+			}
+		}
+
+		origin := question.Origin
+		if origin == "" {
+			origin = "unknown"
+		}
+		summary.Origins[origin]++
+
+		if question.Timestamp == "" {
+			continue
+		}
+		timestamp, ok := ParseTimestamp(question.Timestamp)
+		if !ok {
+			summary.UnparsedDates++
+			continue
+		}
+		if oldest.IsZero() || timestamp.Before(oldest) {
+			oldest = timestamp
+			summary.Oldest = question.Timestamp
+		}
+		if newest.IsZero() || timestamp.After(newest) {
+			newest = timestamp
+			summary.Newest = question.Timestamp
+		}
+	}
+
+	for topic, seen := range numbers {
+		t := Topic{Topic: topic, Questions: len(seen)}
+		for number := range seen {
+			t.Highest = max(t.Highest, number)
+		}
+		for number := 1; number < t.Highest; number++ {
+			if _, ok := seen[number]; !ok {
+				t.Missing = append(t.Missing, number)
+			}
+		}
+		summary.Topics = append(summary.Topics, t)
+	}
+	sort.Slice(summary.Topics, func(i, j int) bool {
+		return summary.Topics[i].Topic < summary.Topics[j].Topic
+	})
+
+	if summary.Questions > 0 {
+		summary.CommentsPerQuestion = float64(summary.Comments) / float64(summary.Questions)
+	}
+	return summary
+}
+
+var timestampLayouts = []string{
+	"January 2, 2006, 3:04 PM",
+	"January 2, 2006, 3 PM",
+	"Jan 2, 2006, 3:04 PM",
+	"Jan 2, 2006, 3 PM",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Parses the site's "June 1, 2021, 8:11 p.m." style timestamps as well as ISO dates
+func ParseTimestamp(raw string) (time.Time, bool) {
+	s := strings.TrimSpace(raw)
+	s = strings.NewReplacer("a.m.", "AM", "p.m.", "PM", "noon", "12 PM", "midnight", "12 AM", "Sept.", "Sep", ".", "").Replace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(raw)); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func (s Summary) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Writes the summary as plain text, which also reads as markdown
+func (s Summary) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Questions: %d (%d with an answer, %d with images)\n", s.Questions, s.WithAnswer, s.WithImages)
+
+	fmt.Fprintln(w, "\nTopics:")
+	for _, topic := range s.Topics {
+		fmt.Fprintf(w, "  Topic %d: %d questions, highest number %d", topic.Topic, topic.Questions, topic.Highest)
+		if len(topic.Missing) > 0 {
+			fmt.Fprintf(w, ", missing %d (%s)", len(topic.Missing), formatNumbers(topic.Missing, 20))
+		}
+		fmt.Fprintln(w)
+	}
+	if s.Unparsed > 0 {
+		fmt.Fprintf(w, "  %d questions without a topic in their link\n", s.Unparsed)
+	}
+
+	fmt.Fprintf(w, "\nQuestion types: %s\n", formatCounts(s.Types))
+	fmt.Fprintf(w, "Answer letters: %s\n", formatCounts(s.AnswerLetters))
+	fmt.Fprintf(w, "Comments: %d across %d questions (%.1f per question)\n", s.Comments, s.WithComments, s.CommentsPerQuestion)
+	if s.Oldest != "" {
+		fmt.Fprintf(w, "Oldest: %s\nNewest: %s\n", s.Oldest, s.Newest)
+	}
+	if s.UnparsedDates > 0 {
+		fmt.Fprintf(w, "Unreadable timestamps: %d\n", s.UnparsedDates)
+	}
+	_, err := fmt.Fprintf(w, "Sources: %s\n", formatCounts(s.Origins))
+	return err
+}
+
+// Formats counts as "B: 12, A: 9", largest first
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %d", key, counts[key]))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatNumbers(numbers []int, limit int) string {
+	var parts []string
+	for i, number := range numbers {
+		if i == limit {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprint(number))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,171 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Report lists the question links touched by an update
+type Report struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Update: %d added, %d changed, %d removed\n", len(r.Added), len(r.Changed), len(r.Removed))
+	for _, section := range []struct {
+		label string
+		links []string
+	}{{"+", r.Added}, {"~", r.Changed}, {"-", r.Removed}} {
+		for _, link := range section.links {
+			fmt.Fprintf(w, "  %s %s\n", section.label, link)
+		}
+	}
+}
+
+// Brings a previous export up to date. New questions are fetched through the whole chain,
+// questions already exported are only refreshed from sources that don't need scraping.
+// An exported question is only dropped when the live crawl or the source it came from
+// lists the exam without it, so a question missing from an incomplete cache is kept
+func Run(chain *fetch.Chain, providerName, grepStr string, previous []models.QuestionData) ([]models.QuestionData, Report, error) {
+	listings, err := chain.Listings(providerName, grepStr)
+	if err != nil {
+		return nil, Report{}, err
+	}
+	listing := fetch.MergeListings(listings)
+	if len(listing) == 0 {
+		return nil, Report{}, fmt.Errorf("no questions listed for provider '%s'", providerName)
+	}
+
+	listedBy := make(map[string]bool, len(listings))
+	for _, l := range listings {
+		listedBy[l.Source] = true
+	}
+
+	listed := make(map[string]struct{}, len(listing))
+	for _, link := range listing {
+		listed[utils.NormalizeQuestionURL(link)] = struct{}{}
+	}
+
+	known := make(map[string]struct{}, len(previous))
+	var kept []string
+	for _, data := range previous {
+		key := utils.NormalizeQuestionURL(data.QuestionLink)
+		known[key] = struct{}{}
+		if _, ok := listed[key]; ok || listedBy["scrape"] || (data.Origin != "" && listedBy[data.Origin]) {
+			continue
+		}
+		// No source that would list this question has been asked, so keep it
+		kept = append(kept, data.QuestionLink)
+	}
+	if len(kept) > 0 {
+		listing = fetch.MergeListings(append(listings, fetch.Listing{Source: "export", Links: kept}))
+	}
+
+	var newLinks, knownLinks []string
+	for _, link := range listing {
+		if _, ok := known[utils.NormalizeQuestionURL(link)]; ok {
+			knownLinks = append(knownLinks, link)
+		} else {
+			newLinks = append(newLinks, link)
+		}
+	}
+
+	fetched := make(map[string]models.QuestionData)
+	collect := func(results []*models.QuestionData) {
+		for _, data := range results {
+			if data != nil {
+				fetched[utils.NormalizeQuestionURL(data.QuestionLink)] = *data
+			}
+		}
+	}
+
+	fmt.Printf("Fetching %d new questions\n", len(newLinks))
+	if len(newLinks) > 0 {
+		collect(chain.FetchLinks(newLinks, true))
+	}
+	if len(knownLinks) > 0 {
+		if offline := chain.Without("scrape"); len(offline.Sources) > 0 {
+			fmt.Printf("Checking %d exported questions for changes\n", len(knownLinks))
+			collect(offline.FetchLinks(knownLinks, false))
+		} else {
+			fmt.Printf("Not checking %d exported questions for changes, that needs a cache, local or mirror source\n", len(knownLinks))
+		}
+	}
+
+	result, report := Merge(previous, listing, fetched)
+	return result, report, nil
+}
+
+// Applies freshly fetched questions to a previous export. Previous questions keep their order,
+// new ones are placed after the question listed just before them, and questions
+// no longer listed are dropped
+func Merge(previous []models.QuestionData, listing []string, fetched map[string]models.QuestionData) ([]models.QuestionData, Report) {
+	var report Report
+
+	listed := make(map[string]int, len(listing))
+	for i, link := range listing {
+		listed[utils.NormalizeQuestionURL(link)] = i
+	}
+
+	var result []models.QuestionData
+	position := make(map[string]int)
+	for _, data := range previous {
+		key := utils.NormalizeQuestionURL(data.QuestionLink)
+		if _, ok := listed[key]; !ok {
+			report.Removed = append(report.Removed, data.QuestionLink)
+			continue
+		}
+		if fresh, ok := fetched[key]; ok {
+			if changed(data, fresh) {
+				report.Changed = append(report.Changed, data.QuestionLink)
+			}
+			data = fresh
+		}
+		position[key] = len(result)
+		result = append(result, data)
+	}
+
+	for i, link := range listing {
+		key := utils.NormalizeQuestionURL(link)
+		fresh, ok := fetched[key]
+		if _, exists := position[key]; exists || !ok {
+			continue
+		}
+
+		insertAt := 0
+		for j := i - 1; j >= 0; j-- {
+			if pos, ok := position[utils.NormalizeQuestionURL(listing[j])]; ok {
+				insertAt = pos + 1
+				break
+			}
+		}
+
+		result = append(result[:insertAt], append([]models.QuestionData{fresh}, result[insertAt:]...)...)
+		for k, pos := range position {
+			if pos >= insertAt {
+				position[k] = pos + 1
+			}
+		}
+		position[key] = insertAt
+		report.Added = append(report.Added, fresh.QuestionLink)
+	}
+
+	return result, report
+}
+
+func changed(old, fresh models.QuestionData) bool {
+	return old.Answer != fresh.Answer || questionText(old) != questionText(fresh)
+}
+
+// Flattens the question so a markdown export compares equal to the data it was written from
+func questionText(data models.QuestionData) string {
+	parts := append([]string{data.Header, data.Content}, data.Questions...)
+	return utils.CleanText(strings.Join(parts, " "))
+}
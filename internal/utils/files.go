@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"encoding/json"
 	"examtopics-downloader/internal/models"
 	"fmt"
+	"io"
 	"log"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 )
 
 func writeFile(filename string, content any) {
@@ -22,18 +27,98 @@ func writeFile(filename string, content any) {
 	}
 }
 
+// Headings of the table of contents and answer key sections, which aren't questions
+const (
+	TOCHeading       = "Table of Contents"
+	AnswerKeyHeading = "Answer Key"
+)
+
+type MarkdownOptions struct {
+	Comments bool
+	// Add a table of contents linking every question, with "back to top" links
+	TOC bool
+	// Add an answer key table at the end
+	AnswerKey bool
+	// Style of the links to headings, GitHubLinks (default) or ObsidianLinks
+	LinkStyle string
+}
+
+// Heading link styles, picked with -toc-style
+const (
+	GitHubLinks   = "github"
+	ObsidianLinks = "obsidian"
+)
+
+// Links to a heading of the same file, as a GitHub anchor or an Obsidian [[#Heading]] link.
+// Obsidian links to the first heading with that text, so repeated titles share a target
+func headingLink(style, label, heading, anchor string, inTable bool) string {
+	if style != ObsidianLinks {
+		if inTable {
+			label = strings.ReplaceAll(label, "|", `\|`)
+		}
+		return fmt.Sprintf("[%s](#%s)", label, anchor)
+	}
+
+	separator := "|"
+	if inTable {
+		separator = `\|`
+	}
+	return fmt.Sprintf("[[#%s%s%s]]", ObsidianHeading(heading), separator, strings.NewReplacer("[", "", "]", "", "|", "").Replace(label))
+}
+
+// Drops the characters Obsidian doesn't allow in links to headings
+func ObsidianHeading(heading string) string {
+	heading = strings.NewReplacer("#", " ", "|", " ", "^", " ", "[", " ", "]", " ").Replace(heading)
+	return strings.Join(strings.Fields(heading), " ")
+}
+
 func WriteData(dataList []models.QuestionData, outputPath string, commentBool bool) {
+	WriteMarkdown(dataList, outputPath, MarkdownOptions{Comments: commentBool})
+}
+
+func WriteMarkdown(dataList []models.QuestionData, outputPath string, opts MarkdownOptions) {
 	file := CreateFile(outputPath)
 	defer file.Close()
 
-	fmt.Fprintf(file, "# Exam Topics Questions\n\n")
-	fmt.Fprintf(file, "@thatonecodes\n\n")
+	const title = "Exam Topics Questions"
+	anchors := NewAnchors()
+	top := anchors.Add(title)
 
+	var questions []models.QuestionData
 	for _, data := range dataList {
-		if data.Title == "" {
-			continue
+		if data.Title != "" {
+			questions = append(questions, data)
 		}
+	}
 
+	// Anchors are handed out in heading order, so repeated titles get the same suffixes as on GitHub
+	var answerKeyAnchor string
+	if opts.TOC {
+		anchors.Add(TOCHeading)
+	}
+	questionAnchors := make([]string, len(questions))
+	for i, data := range questions {
+		questionAnchors[i] = anchors.Add(data.Title)
+	}
+	if opts.AnswerKey {
+		answerKeyAnchor = anchors.Add(AnswerKeyHeading)
+	}
+
+	fmt.Fprintf(file, "# %s\n\n", title)
+	fmt.Fprintf(file, "@thatonecodes\n\n")
+
+	if opts.TOC {
+		fmt.Fprintf(file, "## %s\n\n", TOCHeading)
+		for i, data := range questions {
+			fmt.Fprintf(file, "%d. %s\n", i+1, headingLink(opts.LinkStyle, data.Title, data.Title, questionAnchors[i], false))
+		}
+		if opts.AnswerKey {
+			fmt.Fprintf(file, "\n%s\n", headingLink(opts.LinkStyle, AnswerKeyHeading, AnswerKeyHeading, answerKeyAnchor, false))
+		}
+		fmt.Fprintf(file, "\n----------------------------------------\n\n")
+	}
+
+	for _, data := range questions {
 		fmt.Fprintf(file, "## %s\n\n", data.Title)
 		fmt.Fprintf(file, "%s\n\n", data.Header)
 
@@ -46,15 +131,40 @@ func WriteData(dataList []models.QuestionData, outputPath string, commentBool bo
 		}
 
 		fmt.Fprintf(file, "**Answer: %s**\n\n", data.Answer)
+		if len(data.Votes) > 0 {
+			fmt.Fprintf(file, "**Community vote: %s**\n\n", FormatVotes(data.Votes))
+		}
 		fmt.Fprintf(file, "**Timestamp: %s**\n\n", data.Timestamp)
 		fmt.Fprintf(file, "[View on ExamTopics](%s)\n\n", data.QuestionLink)
+		if len(data.Duplicates) > 0 {
+			fmt.Fprintf(file, "Also asked as: %s\n\n", strings.Join(data.Duplicates, " "))
+		}
 
-		if commentBool {
-			fmt.Fprintf(file, "Comments: %s\n", data.Comments)
+		if opts.Comments {
+			fmt.Fprintf(file, "Comments: %s\n\n", data.Comments)
 		}
 
+		if opts.TOC {
+			fmt.Fprintf(file, "%s\n\n", headingLink(opts.LinkStyle, "Back to top", title, top, false))
+		}
 		fmt.Fprintf(file, "----------------------------------------\n\n")
 	}
+
+	if opts.AnswerKey {
+		fmt.Fprintf(file, "## %s\n\n", AnswerKeyHeading)
+		fmt.Fprintln(file, "| # | Question | Answer | Community |")
+		fmt.Fprintln(file, "| - | -------- | ------ | --------- |")
+		for i, data := range questions {
+			community := ""
+			if answer, share := CommunityAnswer(data.Votes); answer != "" {
+				community = fmt.Sprintf("%s (%.0f%%)", answer, share*100)
+			}
+			fmt.Fprintf(file, "| %d | %s | %s | %s |\n", i+1, headingLink(opts.LinkStyle, data.Title, data.Title, questionAnchors[i], true), data.Answer, community)
+		}
+		if opts.TOC {
+			fmt.Fprintf(file, "\n%s\n", headingLink(opts.LinkStyle, "Back to top", title, top, false))
+		}
+	}
 }
 
 func SaveLinks(filename string, links []models.QuestionData) {
@@ -64,3 +174,23 @@ func SaveLinks(filename string, links []models.QuestionData) {
 	}
 	writeFile(filename, fullLinks)
 }
+
+// Prints exams as an aligned table, or as JSON when asJSON is set
+func WriteExamList(w io.Writer, exams []models.ExamInfo, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exams)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PROVIDER\tEXAM\tNAME\tQUESTIONS")
+	for _, exam := range exams {
+		count := "-"
+		if exam.QuestionCount > 0 {
+			count = strconv.Itoa(exam.QuestionCount)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", exam.Provider, exam.Slug, exam.Name, count)
+	}
+	return table.Flush()
+}
@@ -9,10 +9,12 @@ import (
 	"path"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 func CleanText(raw string) string {
@@ -125,14 +127,114 @@ func GrepString(baseString, searchString string) bool {
 	)
 }
 
-func GrepStringFromCache(baseString, searchString string) bool {
-	baseNorm := normalize(baseString)
-	searchNorm := normalize(searchString)
+// Reduces an exam code or slug to lowercase letters and digits, so "AZ 104" equals "az-104"
+func compactExam(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
 
-	return strings.Contains(
-		baseNorm,
-		searchNorm,
-	)
+// Reports whether a discussion link belongs to the given exam slug, an empty slug matches everything
+func MatchesExam(link, examSlug string) bool {
+	if examSlug == "" {
+		return true
+	}
+	ref, ok := ParseQuestionLink(link)
+	return ok && compactExam(ref.Exam) == compactExam(examSlug)
+}
+
+// Reports whether a cached data file belongs to the given exam slug, an empty slug matches everything
+func MatchesCachedExam(link, examSlug string) bool {
+	if examSlug == "" {
+		return true
+	}
+	return compactExam(ExamNameFromCachedFile(link)) == compactExam(examSlug)
+}
+
+// Returns the exam slug of an exam page link such as /exams/google/professional-cloud-architect/
+func ExamSlugFromLink(link string) string {
+	return path.Base(strings.TrimSuffix(strings.TrimSpace(link), "/"))
+}
+
+// Finds the exams matching a query: an exact code match first, then exams containing it,
+// then the exams closest by edit distance when the query looks like a typo
+func MatchExamSlugs(query string, slugs []string) []string {
+	q := compactExam(query)
+	if q == "" {
+		return nil
+	}
+
+	for _, slug := range slugs {
+		if compactExam(slug) == q {
+			return []string{slug}
+		}
+	}
+
+	var containing []string
+	for _, slug := range slugs {
+		if strings.Contains(compactExam(slug), q) {
+			containing = append(containing, slug)
+		}
+	}
+	if len(containing) > 0 {
+		return containing
+	}
+
+	maxDistance := max(1, len(q)/5)
+	var closest []string
+	best := maxDistance + 1
+	for _, slug := range slugs {
+		distance := levenshtein(q, compactExam(slug))
+		switch {
+		case distance < best:
+			best = distance
+			closest = []string{slug}
+		case distance == best:
+			closest = append(closest, slug)
+		}
+	}
+	return closest
+}
+
+// Returns up to n exams ordered by how close they are to the query
+func ClosestExamSlugs(query string, slugs []string, n int) []string {
+	q := compactExam(query)
+	sorted := make([]string, len(slugs))
+	copy(sorted, slugs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return levenshtein(q, compactExam(sorted[i])) < levenshtein(q, compactExam(sorted[j]))
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
 }
 
 func AddToBaseUrl(addString string) string {
@@ -247,3 +349,273 @@ func TimeSince(startTime time.Time) string {
 	}
 	return fmt.Sprintf("%ds", seconds)
 }
+
+var questionLinkRe = regexp.MustCompile(`/discussions/([^/]+)/view/(\d+)-exam-(.+?)-topic-(\d+)-question-(\d+)`)
+
+// Parses the provider, discussion id, exam slug, topic and question number out of a discussion link
+func ParseQuestionLink(link string) (models.QuestionRef, bool) {
+	match := questionLinkRe.FindStringSubmatch(strings.ToLower(link))
+	if match == nil {
+		return models.QuestionRef{}, false
+	}
+
+	id, _ := strconv.Atoi(match[2])
+	topic, _ := strconv.Atoi(match[4])
+	number, _ := strconv.Atoi(match[5])
+	return models.QuestionRef{
+		Provider: match[1],
+		ID:       id,
+		Exam:     match[3],
+		Topic:    topic,
+		Number:   number,
+	}, true
+}
+
+// Normalizes a question link so the same discussion matches across sources
+func NormalizeQuestionURL(link string) string {
+	link = strings.ToLower(strings.TrimSpace(link))
+	if idx := strings.IndexAny(link, "?#"); idx >= 0 {
+		link = link[:idx]
+	}
+	link = strings.TrimPrefix(link, "https://www.examtopics.com")
+	link = strings.TrimPrefix(link, "http://www.examtopics.com")
+	link = strings.TrimPrefix(link, "https://examtopics.com")
+	link = strings.TrimSuffix(link, "/")
+	link = strings.TrimSuffix(link, "-discussion")
+	if !strings.HasPrefix(link, "/") {
+		link = "/" + link
+	}
+	return AddToBaseUrl(link + "/")
+}
+
+// Returns the exam name of a cached data file, dropping the page suffix and extension
+func ExamNameFromCachedFile(link string) string {
+	if idx := strings.IndexAny(link, "?#"); idx >= 0 {
+		link = link[:idx]
+	}
+	return normalize(path.Base(link))
+}
+
+// Drops questions whose normalized link was already seen, keeping the first occurrence
+func DeduplicateQuestions(data []models.QuestionData) []models.QuestionData {
+	seen := make(map[string]struct{})
+	var unique []models.QuestionData
+	for _, entry := range data {
+		key := NormalizeQuestionURL(entry.QuestionLink)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, entry)
+	}
+	return unique
+}
+
+// Sorts questions by the topic and question number in their links, keeping unparseable links in place at the end
+func SortQuestionsByLink(data []models.QuestionData) []models.QuestionData {
+	sort.SliceStable(data, func(i, j int) bool {
+		refI, okI := ParseQuestionLink(data[i].QuestionLink)
+		refJ, okJ := ParseQuestionLink(data[j].QuestionLink)
+		if !okI || !okJ {
+			return okI && !okJ
+		}
+		if refI.Topic != refJ.Topic {
+			return refI.Topic < refJ.Topic
+		}
+		return refI.Number < refJ.Number
+	})
+	return data
+}
+
+var selectedAnswerRe = regexp.MustCompile(`(?i:selected answer):\s*([A-F]+)\b`)
+
+// Extracts the answer letters from a comment's "Selected Answer: BD" marker
+func ParseSelectedAnswer(text string) string {
+	match := selectedAnswerRe.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return strings.ToUpper(match[1])
+}
+
+// Builds a vote distribution from the answers selected in comments, most voted first
+func VotesFromComments(discussion []models.Comment) []models.Vote {
+	counts := make(map[string]int)
+	for _, comment := range discussion {
+		if comment.SelectedAnswer != "" {
+			counts[comment.SelectedAnswer]++
+		}
+	}
+
+	var votes []models.Vote
+	for answer, count := range counts {
+		votes = append(votes, models.Vote{Answer: answer, Count: count})
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].Count != votes[j].Count {
+			return votes[i].Count > votes[j].Count
+		}
+		return votes[i].Answer < votes[j].Answer
+	})
+	if len(votes) > 0 {
+		votes[0].MostVoted = true
+	}
+	return votes
+}
+
+// Returns the most voted answer and its share of all votes
+func CommunityAnswer(votes []models.Vote) (string, float64) {
+	total := 0
+	best := models.Vote{}
+	for _, vote := range votes {
+		total += vote.Count
+		if vote.Count > best.Count || (vote.MostVoted && vote.Count == best.Count) {
+			best = vote
+		}
+	}
+	if total == 0 {
+		return "", 0
+	}
+	return best.Answer, float64(best.Count) / float64(total)
+}
+
+// Formats votes as "B: 8 votes (80%), A: 2 votes (20%)"
+func FormatVotes(votes []models.Vote) string {
+	total := 0
+	for _, vote := range votes {
+		total += vote.Count
+	}
+
+	var parts []string
+	for _, vote := range votes {
+		if total > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d votes (%d%%)", vote.Answer, vote.Count, vote.Count*100/total))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var formattedVoteRe = regexp.MustCompile(`([A-Z]+): (\d+) votes`)
+
+// Parses the output of FormatVotes back into votes
+func ParseVotes(text string) []models.Vote {
+	var votes []models.Vote
+	for i, match := range formattedVoteRe.FindAllStringSubmatch(text, -1) {
+		count, _ := strconv.Atoi(match[2])
+		votes = append(votes, models.Vote{Answer: match[1], Count: count, MostVoted: i == 0})
+	}
+	return votes
+}
+
+var choiceRe = regexp.MustCompile(`(?m)^\s*(?:\*\*([A-Z]):\*\*|([A-Z])\.)\s*`)
+
+// Splits the stored choice strings ("A. text" when scraped, "**A:** text" when cached) into letters and text
+func ParseChoices(questions []string) []models.Choice {
+	var choices []models.Choice
+	for _, question := range questions {
+		matches := choiceRe.FindAllStringSubmatchIndex(question, -1)
+		for i, match := range matches {
+			end := len(question)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+
+			var letter string
+			if match[2] >= 0 {
+				letter = question[match[2]:match[3]]
+			} else {
+				letter = question[match[4]:match[5]]
+			}
+			text := strings.TrimSpace(question[match[1]:end])
+			text = strings.TrimSpace(strings.TrimSuffix(text, "Most Voted"))
+			choices = append(choices, models.Choice{Letter: letter, Text: text})
+		}
+	}
+	return choices
+}
+
+// Returns the question text, which scraped pages keep in Content and cached data in Header
+func QuestionBody(data models.QuestionData) string {
+	if data.Content != "" && !onlyLinks(data.Content) {
+		// Images are listed by QuestionImages, so they are left out of the text
+		body := markdownImageRe.ReplaceAllString(data.Content, "")
+		return strings.TrimSpace(blankLinesRe.ReplaceAllString(body, "\n\n"))
+	}
+	return data.Header
+}
+
+var (
+	markdownImageRe = regexp.MustCompile(`!\[[^\]]*\]\((\S+?)\)`)
+	blankLinesRe    = regexp.MustCompile(`\n{3,}`)
+)
+
+// Returns the image links of a question, either bare links or markdown images in its content
+func QuestionImages(data models.QuestionData) []string {
+	var images []string
+	for _, match := range markdownImageRe.FindAllStringSubmatch(data.Content, -1) {
+		if strings.HasPrefix(match[1], "http") {
+			images = append(images, match[1])
+		}
+	}
+	for _, field := range strings.Fields(data.Content) {
+		if strings.HasPrefix(field, "http") {
+			images = append(images, field)
+		}
+	}
+	return images
+}
+
+func onlyLinks(s string) bool {
+	for _, field := range strings.Fields(s) {
+		if !strings.HasPrefix(field, "http") {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalizes an answer such as "b, d" to "BD"
+func NormalizeAnswer(answer string) string {
+	var letters []string
+	for _, r := range strings.ToUpper(answer) {
+		if r >= 'A' && r <= 'Z' && !slices.Contains(letters, string(r)) {
+			letters = append(letters, string(r))
+		}
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
+
+// Builds a heading anchor the way GitHub, VS Code and most previews do: lowercase,
+// spaces to dashes and punctuation dropped
+func MarkdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Anchors hands out unique heading anchors, suffixing repeats with -1, -2... like GitHub
+type Anchors struct {
+	seen map[string]int
+}
+
+func NewAnchors() *Anchors {
+	return &Anchors{seen: make(map[string]int)}
+}
+
+func (a *Anchors) Add(heading string) string {
+	anchor := MarkdownAnchor(heading)
+	count, ok := a.seen[anchor]
+	a.seen[anchor] = count + 1
+	if ok {
+		return fmt.Sprintf("%s-%d", anchor, count)
+	}
+	return anchor
+}
@@ -0,0 +1,242 @@
+package web
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+const pageSize = 50
+
+// Server serves a browsable, searchable view of one set of questions
+type Server struct {
+	title     string
+	questions []question
+	topics    []int
+	templates *template.Template
+}
+
+// question is a QuestionData prepared for the templates
+type question struct {
+	ID        int
+	Data      models.QuestionData
+	Ref       models.QuestionRef
+	Body      string
+	Images    []string
+	Choices   []models.Choice
+	Community string
+	Multiple  bool
+	search    string
+}
+
+func NewServer(title string, dataList []models.QuestionData) (*Server, error) {
+	templates, err := template.New("").Funcs(template.FuncMap{
+		"inc":     func(i int) int { return i + 1 },
+		"dec":     func(i int) int { return i - 1 },
+		"percent": func(f float64) string { return strconv.Itoa(int(f*100+0.5)) + "%" },
+		"votes":   utils.FormatVotes,
+	}).ParseFS(templateFiles, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{title: title, templates: templates}
+	seenTopics := make(map[int]bool)
+	for i, data := range dataList {
+		ref, _ := utils.ParseQuestionLink(data.QuestionLink)
+		community, _ := utils.CommunityAnswer(data.Votes)
+		q := question{
+			ID:        i + 1,
+			Data:      data,
+			Ref:       ref,
+			Body:      utils.QuestionBody(data),
+			Images:    utils.QuestionImages(data),
+			Choices:   utils.ParseChoices(data.Questions),
+			Community: community,
+			Multiple:  len(utils.NormalizeAnswer(data.Answer)) > 1,
+		}
+
+		var text []string
+		text = append(text, data.Title, utils.CleanText(q.Body))
+		for _, choice := range q.Choices {
+			text = append(text, choice.Text)
+		}
+		q.search = strings.ToLower(strings.Join(text, " "))
+
+		s.questions = append(s.questions, q)
+		if ref.Topic > 0 && !seenTopics[ref.Topic] {
+			seenTopics[ref.Topic] = true
+			s.topics = append(s.topics, ref.Topic)
+		}
+	}
+	sort.Ints(s.topics)
+
+	return s, nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleList)
+	mux.HandleFunc("GET /questions/{id}", s.handleQuestion)
+	mux.HandleFunc("GET /practice", s.handlePracticeForm)
+	mux.HandleFunc("POST /practice", s.handlePracticeResult)
+	return mux
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, data map[string]any) {
+	data["Title"] = s.title
+	data["Topics"] = s.topics
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("failed to render %s: %v", name, err)
+	}
+}
+
+// Returns the questions matching the search terms and topic
+func (s *Server) filter(query string, topic int) []question {
+	terms := strings.Fields(strings.ToLower(query))
+
+	var matches []question
+	for _, q := range s.questions {
+		if topic > 0 && q.Ref.Topic != topic {
+			continue
+		}
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(q.search, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, q)
+		}
+	}
+	return matches
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	topic, _ := strconv.Atoi(r.URL.Query().Get("topic"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(page, 1)
+
+	matches := s.filter(query, topic)
+	pages := max((len(matches)+pageSize-1)/pageSize, 1)
+	page = min(page, pages)
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, len(matches))
+
+	pageLink := func(p int) string {
+		values := url.Values{"page": {strconv.Itoa(p)}}
+		if query != "" {
+			values.Set("q", query)
+		}
+		if topic > 0 {
+			values.Set("topic", strconv.Itoa(topic))
+		}
+		return "/?" + values.Encode()
+	}
+
+	data := map[string]any{
+		"Query":     query,
+		"Topic":     topic,
+		"Questions": matches[start:end],
+		"Total":     len(matches),
+		"Page":      page,
+		"Pages":     pages,
+	}
+	if page > 1 {
+		data["PrevLink"] = pageLink(page - 1)
+	}
+	if page < pages {
+		data["NextLink"] = pageLink(page + 1)
+	}
+	s.render(w, "list.html", data)
+}
+
+func (s *Server) handleQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 || id > len(s.questions) {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.render(w, "question.html", map[string]any{
+		"Question":    s.questions[id-1],
+		"HasPrev":     id > 1,
+		"HasNext":     id < len(s.questions),
+		"ShowAnswers": r.URL.Query().Get("answers") == "1",
+	})
+}
+
+func (s *Server) handlePracticeForm(w http.ResponseWriter, r *http.Request) {
+	count, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	topic, _ := strconv.Atoi(r.URL.Query().Get("topic"))
+	seed, err := strconv.ParseInt(r.URL.Query().Get("seed"), 10, 64)
+	if err != nil {
+		seed = time.Now().UnixNano()
+	}
+
+	data := map[string]any{"Topic": topic, "Seed": seed, "Count": count}
+	if count > 0 {
+		pool := s.filter("", topic)
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		data["Questions"] = pool[:min(count, len(pool))]
+	}
+	s.render(w, "practice.html", data)
+}
+
+type practiceResult struct {
+	Question question
+	Given    string
+	Correct  bool
+}
+
+func (s *Server) handlePracticeResult(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var results []practiceResult
+	correct := 0
+	for _, idStr := range r.PostForm["id"] {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id < 1 || id > len(s.questions) {
+			continue
+		}
+		q := s.questions[id-1]
+		given := utils.NormalizeAnswer(strings.Join(r.PostForm["answer-"+idStr], ""))
+		result := practiceResult{Question: q, Given: given, Correct: given == utils.NormalizeAnswer(q.Data.Answer)}
+		if result.Correct {
+			correct++
+		}
+		results = append(results, result)
+	}
+
+	score := 0.0
+	if len(results) > 0 {
+		score = float64(correct) / float64(len(results))
+	}
+	s.render(w, "results.html", map[string]any{
+		"Results": results,
+		"Correct": correct,
+		"Total":   len(results),
+		"Score":   score,
+	})
+}
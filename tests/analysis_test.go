@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/analysis"
+	"examtopics-downloader/internal/models"
+)
+
+func TestDisagreementsFlagsContradictedAnswers(t *testing.T) {
+	questions := []models.QuestionData{
+		{
+			Title:        "Agreed",
+			Answer:       "A",
+			QuestionLink: "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/",
+			Votes:        []models.Vote{{Answer: "A", Count: 20, MostVoted: true}, {Answer: "B", Count: 2}},
+		},
+		{
+			Title:        "Contradicted",
+			Answer:       "A",
+			QuestionLink: "https://www.examtopics.com/discussions/lpi/view/2-exam-010-160-topic-1-question-2/",
+			Votes:        []models.Vote{{Answer: "C", Count: 15, MostVoted: true}, {Answer: "A", Count: 1}},
+			Discussion: []models.Comment{
+				{Poster: "x", Upvotes: 30, SelectedAnswer: "C"},
+				{Poster: "y", Upvotes: 1, SelectedAnswer: "A"},
+			},
+		},
+		{
+			Title:        "Comments only",
+			Answer:       "BD",
+			QuestionLink: "https://www.examtopics.com/discussions/lpi/view/3-exam-010-160-topic-1-question-3/",
+			Discussion:   []models.Comment{{Poster: "z", Upvotes: 4, SelectedAnswer: "DB"}},
+		},
+	}
+
+	agreed := analysis.Assess(questions[0], analysis.DefaultTopComments)
+	contradicted := analysis.Assess(questions[1], analysis.DefaultTopComments)
+	if agreed.Contradicted || agreed.Confidence <= 0.7 {
+		t.Errorf("Expected a confident, agreed answer, got %+v", agreed)
+	}
+	if !contradicted.Contradicted || contradicted.Community != "C" || contradicted.Confidence >= 0.3 {
+		t.Errorf("Expected a contradicted answer with low confidence, got %+v", contradicted)
+	}
+	if a := analysis.Assess(questions[2], analysis.DefaultTopComments); a.Contradicted || a.Community != "BD" {
+		t.Errorf("Expected the comments to agree with BD regardless of letter order, got %+v", a)
+	}
+
+	report := analysis.Disagreements(questions, analysis.DefaultTopComments, 0)
+	if report.Total != 3 || len(report.Disagreements) != 1 || report.Disagreements[0].Title != "Contradicted" {
+		t.Fatalf("Expected only the contradicted question to be reported, got %+v", report)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteMarkdown(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "| [Contradicted](") || !strings.Contains(out.String(), "C (94%)") {
+		t.Errorf("Unexpected markdown report:\n%s", out.String())
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"examtopics-downloader/internal/api"
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+)
+
+func TestAPIServesExamQuestions(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "010-160_1.json"), []byte(cachedPageJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := fetch.NewChain("local", fetch.SourceOptions{LocalDir: localDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(api.NewServer(chain, time.Minute).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/questions/100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 before the exam is loaded, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/exams/lpi:010-160/questions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var questions []models.QuestionData
+	json.NewDecoder(resp.Body).Decode(&questions)
+	resp.Body.Close()
+	if len(questions) != 1 || questions[0].Answer != "A" {
+		t.Fatalf("Expected the cached question, got %+v", questions)
+	}
+
+	resp, err = http.Get(ts.URL + "/questions/100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the question to be served once its exam is loaded, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIFetchesQuestionOnDemand(t *testing.T) {
+	const (
+		link  = "https://www.examtopics.com/discussions/lpi/view/100-exam-010-160-topic-1-question-1-discussion/"
+		other = "https://www.examtopics.com/discussions/lpi/view/999-exam-010-160-topic-1-question-9-discussion/"
+	)
+	scrape := newFakeSource("scrape", map[string]string{link: "A"})
+	ts := httptest.NewServer(api.NewServer(&fetch.Chain{Sources: []fetch.Source{scrape}}, time.Minute).Handler())
+	defer ts.Close()
+
+	get := func(path string) (int, models.QuestionData) {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var question models.QuestionData
+		json.NewDecoder(resp.Body).Decode(&question)
+		return resp.StatusCode, question
+	}
+
+	if status, question := get("/questions/100?link=" + url.QueryEscape(link)); status != http.StatusOK || question.Answer != "A" {
+		t.Errorf("Expected the linked question, got %d %+v", status, question)
+	}
+	if scrape.listed {
+		t.Error("Expected the question to be fetched without listing its exam")
+	}
+
+	if status, _ := get("/questions/999?link=" + url.QueryEscape(other)); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a question no source has, got %d", status)
+	}
+	if status, _ := get("/questions/100?link=" + url.QueryEscape(other)); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a link to another question, got %d", status)
+	}
+}
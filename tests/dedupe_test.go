@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"examtopics-downloader/internal/dedupe"
+	"examtopics-downloader/internal/models"
+)
+
+func TestFindGroupsAcrossExams(t *testing.T) {
+	questions := []models.QuestionData{
+		{Header: "You have an Azure subscription that contains a virtual network named VNet1. You need to ensure that VM1 can connect to VM2.", Questions: []string{"A. peering", "B. gateway"}, QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/1-exam-az-104-topic-1-question-1/"},
+		{Header: "A totally different question about storage accounts, blobs and lifecycle management rules.", Questions: []string{"A. tiering"}, QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/2-exam-az-104-topic-1-question-2/"},
+		{Header: "You have an Azure subscription that contains a virtual network named VNet1. You need to ensure that VM1 can connect to VM2!", Questions: []string{"A. peering", "B. gateway"}, QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/9-exam-az-103-topic-2-question-5/"},
+	}
+
+	groups := dedupe.FindGroups(questions, dedupe.DefaultThreshold)
+	if len(groups) != 1 || len(groups[0]) != 2 || groups[0][0] != 0 || groups[0][1] != 2 {
+		t.Fatalf("Expected questions 0 and 2 to be grouped, got %v", groups)
+	}
+
+	overlaps := dedupe.CrossExamOverlap(questions, groups)
+	if len(overlaps) != 1 || overlaps[0].Groups != 1 {
+		t.Errorf("Expected one shared question between az-103 and az-104, got %+v", overlaps)
+	}
+
+	collapsed := dedupe.Collapse(questions, groups)
+	if len(collapsed) != 2 {
+		t.Fatalf("Expected 2 questions after collapsing, got %d", len(collapsed))
+	}
+	if len(collapsed[0].Duplicates) != 1 || collapsed[0].Duplicates[0] != questions[2].QuestionLink {
+		t.Errorf("Expected the kept question to list its duplicate, got %v", collapsed[0].Duplicates)
+	}
+}
+
+func TestFindGroupsKeepsImageQuestionsApart(t *testing.T) {
+	questions := []models.QuestionData{
+		{Header: "HOTSPOT -", Content: "https://www.examtopics.com/assets/media/exam-media/04223/0001100001.png", QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/1-exam-az-104-topic-1-question-1/"},
+		{Header: "HOTSPOT -", Content: "https://www.examtopics.com/assets/media/exam-media/04223/0002300001.png", QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/2-exam-az-104-topic-1-question-2/"},
+		{Header: "DRAG DROP - Select and Place:", Content: "https://www.examtopics.com/assets/media/exam-media/04223/0003400001.png", QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/3-exam-az-104-topic-1-question-3/"},
+		{Header: "DRAG DROP - Select and Place:", Content: "https://www.examtopics.com/assets/media/exam-media/04223/0004500001.png", QuestionLink: "https://www.examtopics.com/discussions/microsoft/view/4-exam-az-104-topic-1-question-4/"},
+	}
+
+	if groups := dedupe.FindGroups(questions, dedupe.DefaultThreshold); len(groups) != 0 {
+		t.Errorf("Expected image-only questions with different images not to be grouped, got %v", groups)
+	}
+
+	questions[1].Content = questions[0].Content
+	if groups := dedupe.FindGroups(questions, dedupe.DefaultThreshold); len(groups) != 1 || len(groups[0]) != 2 {
+		t.Errorf("Expected the questions sharing an image to be grouped, got %v", groups)
+	}
+}
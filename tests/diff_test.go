@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"testing"
+
+	"examtopics-downloader/internal/diff"
+	"examtopics-downloader/internal/models"
+)
+
+func TestDiffReportsChanges(t *testing.T) {
+	oldData := sampleQuestions()
+	oldData[0].Votes = []models.Vote{{Answer: "A", Count: 8}, {Answer: "B", Count: 2}}
+
+	newData := sampleQuestions()[:1]
+	newData[0].Answer = "B"
+	newData[0].Votes = []models.Vote{{Answer: "B", Count: 12}, {Answer: "A", Count: 9}}
+	newData[0].Discussion = []models.Comment{
+		{Poster: "alice", Content: "Selected Answer: B, ls only lists", Upvotes: 15},
+		{Poster: "bob", Content: "agree", Upvotes: 1},
+	}
+	newData = append(newData, models.QuestionData{
+		Title:        "Exam 010-160 topic 1 question 4 discussion",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/103-exam-010-160-topic-1-question-4-discussion/",
+	})
+
+	result := diff.Compare(oldData, newData, 10)
+
+	if len(result.Added) != 1 || len(result.Removed) != 1 {
+		t.Errorf("Expected one added and one removed question, got %+v", result)
+	}
+	if len(result.AnswerChanges) != 1 || result.AnswerChanges[0].New != "B" {
+		t.Errorf("Expected the answer change A -> B, got %+v", result.AnswerChanges)
+	}
+	if len(result.VoteShifts) != 1 || result.VoteShifts[0].NewMajority != "B" {
+		t.Errorf("Expected the vote majority to shift to B, got %+v", result.VoteShifts)
+	}
+	if len(result.NewComments) != 1 || result.NewComments[0].Comment.Poster != "alice" {
+		t.Errorf("Expected only alice's upvoted comment, got %+v", result.NewComments)
+	}
+}
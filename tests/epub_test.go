@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+)
+
+func TestEPUBExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	}))
+	defer server.Close()
+
+	questions := sampleQuestions()
+	questions[0].Content = server.URL + "/image1.png"
+	questions = append(questions, models.QuestionData{
+		Title:        "Exam 010-160 topic 2 question 1 discussion",
+		Header:       "Which file holds <user> accounts & groups?",
+		Questions:    []string{"A. /etc/passwd", "B. /etc/hosts"},
+		Answer:       "A",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/200-exam-010-160-topic-2-question-1-discussion/",
+	})
+
+	path := filepath.Join(t.TempDir(), "out.epub")
+	if err := export.Write(questions, path, export.Options{AnswerKey: true}); err != nil {
+		t.Fatalf("Failed writing the EPUB: %v", err)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	if archive.File[0].Name != "mimetype" || archive.File[0].Method != zip.Store {
+		t.Errorf("Expected an uncompressed mimetype first, got %s", archive.File[0].Name)
+	}
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		files[file.Name] = string(content)
+
+		// Every document must be well-formed XML for e-readers to open it
+		if strings.HasSuffix(file.Name, ".xhtml") || strings.HasSuffix(file.Name, ".opf") || strings.HasSuffix(file.Name, ".ncx") || strings.HasSuffix(file.Name, ".xml") {
+			decoder := xml.NewDecoder(strings.NewReader(files[file.Name]))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Errorf("%s is not well-formed: %v", file.Name, err)
+					break
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"OEBPS/chapter-1.xhtml", "OEBPS/chapter-2.xhtml", "OEBPS/nav.xhtml", "OEBPS/images/image-1.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the EPUB", name)
+		}
+	}
+	if !strings.Contains(files["OEBPS/chapter-1.xhtml"], `epub:type="endnotes"`) || !strings.Contains(files["OEBPS/chapter-1.xhtml"], `src="images/image-1.png"`) {
+		t.Errorf("Expected end-of-chapter answers and the embedded image:\n%s", files["OEBPS/chapter-1.xhtml"])
+	}
+	if !strings.Contains(files["OEBPS/content.opf"], `media-type="image/png"`) {
+		t.Errorf("Expected the image in the package manifest:\n%s", files["OEBPS/content.opf"])
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+// Pages of a fake examtopics.com, keyed by path
+var examPages = map[string]string{
+	"/exams/": `<a href="/exams/lpi/">LPI</a> <a href="https://www.examtopics.com/exams/microsoft/">Microsoft</a>
+<a href="/exams/lpi/010-160/">not a provider</a>`,
+	"/exams/lpi/": `<ul><li><a href="/exams/lpi/010-160/">Linux Essentials</a> 80 questions</li>
+<li><a class="popular-exam-link" href="/exams/lpi/101-500/">LPIC-1 Exam 101</a></li></ul>`,
+	"/exams/microsoft/": `<ul><li><a href="/exams/microsoft/az-104/">Microsoft Azure Administrator</a> 1,024 questions</li>
+<li><a href="/exams/microsoft/az-104/">Azure Administrator</a></li></ul>`,
+}
+
+// rewriteTransport sends every request to the test server instead of its own host
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func serveExamPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := examPages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html><body>" + page + "</body></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	previous := fetch.SetHTTPClient(&http.Client{Transport: rewriteTransport{target}})
+	t.Cleanup(func() { fetch.SetHTTPClient(previous) })
+}
+
+func TestListProvidersAndExams(t *testing.T) {
+	serveExamPages(t)
+
+	providers, err := fetch.ListProviders()
+	if err != nil || !reflect.DeepEqual(providers, []string{"lpi", "microsoft"}) {
+		t.Fatalf("Expected lpi and microsoft, got %v (%v)", providers, err)
+	}
+
+	exams, err := fetch.ListProviderExamInfo("microsoft")
+	if err != nil || len(exams) != 1 {
+		t.Fatalf("Expected the repeated exam to be listed once, got %+v (%v)", exams, err)
+	}
+	if exams[0].Name != "Microsoft Azure Administrator" || exams[0].QuestionCount != 1024 {
+		t.Errorf("Expected the name and question count to be kept, got %+v", exams[0])
+	}
+}
+
+func TestSearchExams(t *testing.T) {
+	serveExamPages(t)
+
+	exams, err := fetch.SearchExams("linux")
+	if err != nil || len(exams) != 1 || exams[0].Slug != "010-160" || exams[0].Provider != "lpi" {
+		t.Errorf("Expected the exam to be found by name, got %+v (%v)", exams, err)
+	}
+
+	exams, err = fetch.SearchExams("az104")
+	if err != nil || len(exams) != 1 || exams[0].Slug != "az-104" {
+		t.Errorf("Expected the exam to be found by code, got %+v (%v)", exams, err)
+	}
+}
+
+func TestWriteExamList(t *testing.T) {
+	exams := []models.ExamInfo{
+		{Provider: "lpi", Slug: "010-160", Name: "Linux Essentials", QuestionCount: 80},
+		{Provider: "microsoft", Slug: "az-104", Name: "Azure Administrator"},
+	}
+
+	var table bytes.Buffer
+	if err := utils.WriteExamList(&table, exams, false); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PROVIDER") {
+		t.Fatalf("Expected a header and 2 rows, got:\n%s", table.String())
+	}
+	if strings.Index(lines[1], "010-160") != strings.Index(lines[0], "EXAM") || !strings.HasSuffix(lines[2], "-") {
+		t.Errorf("Expected aligned columns and '-' for a missing count, got:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := utils.WriteExamList(&out, exams, true); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []models.ExamInfo
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, exams) {
+		t.Errorf("Expected the exams back from the JSON, got %+v (%v)", decoded, err)
+	}
+}
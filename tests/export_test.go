@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/update"
+	"examtopics-downloader/internal/utils"
+)
+
+func sampleQuestions() []models.QuestionData {
+	return []models.QuestionData{
+		{
+			Title:        "Exam 010-160 topic 1 question 1 discussion",
+			Header:       "Which command lists files?",
+			Questions:    []string{"A. ls", "B. cd"},
+			Answer:       "A",
+			Timestamp:    "Jan. 1, 2021, 1:00 p.m.",
+			QuestionLink: "https://www.examtopics.com/discussions/lpi/view/100-exam-010-160-topic-1-question-1-discussion/",
+		},
+		{
+			Title:        "Exam 010-160 topic 1 question 3 discussion",
+			Header:       "Which command changes directory?",
+			Questions:    []string{"A. ls", "B. cd"},
+			Answer:       "B",
+			Timestamp:    "Jan. 3, 2021, 1:00 p.m.",
+			QuestionLink: "https://www.examtopics.com/discussions/lpi/view/102-exam-010-160-topic-1-question-3-discussion/",
+		},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	questions := sampleQuestions()
+
+	for _, name := range []string{"out.md", "out.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := export.Write(questions, path, export.Options{}); err != nil {
+			t.Fatalf("Failed writing %s: %v", name, err)
+		}
+
+		loaded, err := export.Load(path)
+		if err != nil {
+			t.Fatalf("Failed loading %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(loaded, questions) {
+			t.Errorf("Round trip through %s changed the data:\n got %+v\nwant %+v", name, loaded, questions)
+		}
+	}
+}
+
+func TestUpdateMergeKeepsOrder(t *testing.T) {
+	previous := sampleQuestions()
+	changedAnswer := previous[1]
+	changedAnswer.Answer = "A"
+	added := models.QuestionData{
+		Title:        "Exam 010-160 topic 1 question 2 discussion",
+		Answer:       "C",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/",
+	}
+
+	// Question 1 was removed from the site and question 2 was published
+	listing := []string{added.QuestionLink, previous[1].QuestionLink}
+
+	fetched := map[string]models.QuestionData{
+		utils.NormalizeQuestionURL(added.QuestionLink):         added,
+		utils.NormalizeQuestionURL(changedAnswer.QuestionLink): changedAnswer,
+	}
+
+	merged, report := update.Merge(previous, listing, fetched)
+	if len(merged) != 2 || merged[0].Title != added.Title || merged[1].Answer != "A" {
+		t.Fatalf("Unexpected merge result: %+v", merged)
+	}
+	if len(report.Added) != 1 || len(report.Changed) != 1 || len(report.Removed) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestUpdateKeepsQuestionsMissingFromCache(t *testing.T) {
+	previous := sampleQuestions()
+	previous[0].Origin = "scrape"
+	previous[1].Origin = "cache"
+
+	const added = "https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2-discussion/"
+	cache := newFakeSource("cache", map[string]string{added: "C"})
+
+	// Question 1 was scraped before and is missing from the cache, question 3 was
+	// cached and has since been removed from it
+	merged, report, err := update.Run(&fetch.Chain{Sources: []fetch.Source{cache}}, "lpi", "010-160", previous)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	var links []string
+	for _, data := range merged {
+		links = append(links, data.QuestionLink)
+	}
+	if want := []string{previous[0].QuestionLink, added}; !reflect.DeepEqual(links, want) {
+		t.Errorf("Expected the scraped question to be kept, got %v", links)
+	}
+	if !reflect.DeepEqual(report.Removed, []string{previous[1].QuestionLink}) || len(report.Added) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/htmlmd"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+const questionBodyHTML = `<div class="card-text">
+	You deploy the following   manifest:<br>
+	<pre class="language-yaml">apiVersion: v1
+kind: Pod
+metadata:
+  name: web</pre>
+	Which <b>two</b> statements are true?
+	<img src="/assets/media/exam-media/04223/0000100001.png">
+	<ul><li>Item one</li><li>Item <code>two</code>
+		<ol><li>Nested</li></ol></li></ul>
+	<table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>
+	<form><a href="#">Forgot my password</a><button>Log in</button></form>
+</div>`
+
+func TestHTMLToMarkdownKeepsStructure(t *testing.T) {
+	got := htmlmd.FromString(questionBodyHTML, "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/")
+
+	want := []string{
+		"You deploy the following manifest:",
+		"```yaml\napiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n```",
+		"Which **two** statements are true?",
+		"![](https://www.examtopics.com/assets/media/exam-media/04223/0000100001.png)",
+		"- Item one\n- Item `two`\n  1. Nested",
+		"| Name | Value |\n| --- | --- |\n| a\\|b | 1 |",
+	}
+	for _, part := range want {
+		if !strings.Contains(got, part) {
+			t.Errorf("Expected the markdown to contain %q, got:\n%s", part, got)
+		}
+	}
+	for _, chrome := range []string{"Forgot my password", "Log in"} {
+		if strings.Contains(got, chrome) {
+			t.Errorf("Expected %q to be removed, got:\n%s", chrome, got)
+		}
+	}
+}
+
+func TestScrapedContentKeepsCodeAndImages(t *testing.T) {
+	page := `<html><head>
+<link rel="canonical" href="https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2-discussion/">
+</head><body><h1>Exam 010-160 topic 1 question 2 discussion</h1>` + questionBodyHTML + `
+<p class="card-text question-answer">Suggested Answer: <span class="correct-answer">B</span></p></body></html>`
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(data.Content, "  name: web\n```") || !strings.Contains(data.Content, "\n\nSuggested Answer: B") {
+		t.Errorf("Expected the code block and answer to be kept, got:\n%s", data.Content)
+	}
+
+	images := utils.QuestionImages(*data)
+	if len(images) != 1 || images[0] != "https://www.examtopics.com/assets/media/exam-media/04223/0000100001.png" {
+		t.Errorf("Expected the image to be found, got %v", images)
+	}
+	if body := utils.QuestionBody(*data); strings.Contains(body, "![](") {
+		t.Errorf("Expected images to be left out of the body, got:\n%s", body)
+	}
+}
+
+func TestQuestionImagesFromCachedLinks(t *testing.T) {
+	data := models.QuestionData{Content: "https://img.example/a.png\nhttps://img.example/b.png"}
+	if images := utils.QuestionImages(data); len(images) != 2 {
+		t.Errorf("Expected 2 bare image links, got %v", images)
+	}
+}
+
+func TestHTMLToMarkdownKeepsNbspIndentation(t *testing.T) {
+	got := htmlmd.FromString("metadata:<br>&nbsp;&nbsp;name: web<br>\n\t&nbsp;&nbsp;&nbsp;&nbsp;labels: {}<br>spec:&nbsp;&nbsp;{}", "")
+	want := "metadata:\n  name: web\n    labels: {}\nspec: {}"
+	if got != want {
+		t.Errorf("Expected the &nbsp; indentation to be kept:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func TestCachedQuestionTextIsConvertedToMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	page := `{"pageProps":{"questions":[{"question_text":"You deploy:<br>&nbsp;&nbsp;name: web<br><img src=\"/assets/media/q1.png\">",
+"answer":"A","choices":{"A":"yes"},"url":"https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/"}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "010-160_1.json"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewLocalSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "You deploy:\n  name: web\n\n![](https://www.examtopics.com/assets/media/q1.png)"
+	if data.Header != want {
+		t.Errorf("Expected the question text as markdown:\n%q\ngot:\n%q", want, data.Header)
+	}
+}
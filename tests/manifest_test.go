@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/manifest"
+)
+
+func writeManifest(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	manifests := map[string]string{
+		"exams.yaml": `exams:
+  - provider: lpi
+    search: 010-160
+    output: lpi.md
+  - provider: microsoft
+    search: az-104
+    output: az.json
+    format: json
+    comments: true
+`,
+		"exams.json": `{"exams": [
+  {"provider": "lpi", "search": "010-160", "output": "lpi.md"},
+  {"provider": "microsoft", "search": "az-104", "output": "az.json", "format": "json", "comments": true}
+]}`,
+		"exams.toml": `[[exams]]
+provider = "lpi"
+search = "010-160"
+output = "lpi.md"
+
+[[exams]]
+provider = "microsoft"
+search = "az-104"
+output = "az.json"
+format = "json"
+comments = true
+`,
+	}
+
+	for name, content := range manifests {
+		m, err := manifest.Load(writeManifest(t, name, content))
+		if err != nil {
+			t.Fatalf("%s: failed to load: %v", name, err)
+		}
+		if len(m.Exams) != 2 {
+			t.Fatalf("%s: expected 2 exams, got %+v", name, m.Exams)
+		}
+		second := m.Exams[1]
+		if second.Provider != "microsoft" || second.Search != "az-104" || second.Format != "json" || !second.Comments {
+			t.Errorf("%s: expected every field to be read, got %+v", name, second)
+		}
+	}
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	if _, err := manifest.Load(writeManifest(t, "exams.yaml", "exams:\n  - provider: lpi\n    output: lpi.md\n")); err == nil || !strings.Contains(err.Error(), "entry 1") {
+		t.Errorf("Expected an entry without a search to be rejected, got %v", err)
+	}
+	if _, err := manifest.Load(writeManifest(t, "exams.txt", "")); err == nil {
+		t.Error("Expected an unknown extension to be rejected")
+	}
+	if _, err := manifest.Load(writeManifest(t, "exams.json", "{")); err == nil {
+		t.Error("Expected invalid JSON to be rejected")
+	}
+}
+
+// Serves the exam pages and a cached copy of two lpi exams, counting requests per path
+func serveCachedExams(t *testing.T) map[string]int {
+	const contents = "/repos/thatonecodes/examtopics-data/contents/Lpi"
+	cached := map[string]string{
+		"010-160_1.json": `{"pageProps": {"questions": [{"question_text": "Which command lists files?", "answer": "A", "choices": {"A": "ls", "B": "cd"}, "url": "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1-discussion/"}]}}`,
+		"101-500_1.json": `{"pageProps": {"questions": [{"question_text": "Which runlevel halts the system?", "answer": "A", "choices": {"A": "0", "B": "6"}, "url": "https://www.examtopics.com/discussions/lpi/view/2-exam-101-500-topic-1-question-1-discussion/"}]}}`,
+	}
+
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == contents:
+			var listing []map[string]string
+			for name := range cached {
+				listing = append(listing, map[string]string{"name": name, "url": "https://api.github.com" + contents + "/" + name + "?ref=main"})
+			}
+			json.NewEncoder(w).Encode(listing)
+		case strings.HasPrefix(r.URL.Path, contents+"/"):
+			name := strings.TrimPrefix(r.URL.Path, contents+"/")
+			json.NewEncoder(w).Encode(map[string]string{"download_url": "https://raw.githubusercontent.com/data/" + name})
+		case strings.HasPrefix(r.URL.Path, "/data/"):
+			w.Write([]byte(cached[strings.TrimPrefix(r.URL.Path, "/data/")]))
+		case examPages[r.URL.Path] != "":
+			w.Write([]byte("<html><body>" + examPages[r.URL.Path] + "</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	previous := fetch.SetHTTPClient(&http.Client{Transport: rewriteTransport{target}})
+	t.Cleanup(func() { fetch.SetHTTPClient(previous) })
+	return requests
+}
+
+func TestRunManifestFetchesListingsOncePerProvider(t *testing.T) {
+	requests := serveCachedExams(t)
+	dir := t.TempDir()
+
+	m := &manifest.Manifest{Exams: []manifest.Entry{
+		{Provider: "lpi", Search: "010-160", Output: filepath.Join(dir, "essentials.json")},
+		{Provider: "LPI", Search: "101500", Output: filepath.Join(dir, "lpic1.json")},
+	}}
+	if err := manifest.Run(m, manifest.RunOptions{}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if requests["/exams/lpi/"] != 1 || requests["/repos/thatonecodes/examtopics-data/contents/Lpi"] != 1 {
+		t.Errorf("Expected the exam page and cached listing to be fetched once, got %v", requests)
+	}
+
+	for output, want := range map[string]string{"essentials.json": "lists files", "lpic1.json": "halts the system"} {
+		data, err := export.Load(filepath.Join(dir, output))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", output, err)
+		}
+		if len(data) != 1 || !strings.Contains(data[0].Header, want) {
+			t.Errorf("Expected %s to hold only its own exam, got %+v", output, data)
+		}
+	}
+}
+
+func TestRunManifestContinuesAfterAFailedProvider(t *testing.T) {
+	serveCachedExams(t)
+	dir := t.TempDir()
+
+	// The first provider has no pages at all, so its crawl fails
+	m := &manifest.Manifest{Exams: []manifest.Entry{
+		{Provider: "gone", Search: "exam-1", Output: filepath.Join(dir, "gone.json")},
+		{Provider: "lpi", Search: "010-160", Output: filepath.Join(dir, "essentials.json")},
+	}}
+	err := manifest.Run(m, manifest.RunOptions{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("Expected only the failed provider's entry to be reported, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "essentials.json")); err != nil {
+		t.Errorf("Expected the next provider to still be exported: %v", err)
+	}
+}
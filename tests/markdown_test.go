@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/htmlmd"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+func TestMarkdownTOCAndAnswerKey(t *testing.T) {
+	questions := sampleQuestions()
+	questions[1].Title = questions[0].Title
+
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := export.Write(questions, path, export.Options{TOC: true, AnswerKey: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	out := string(raw)
+	for _, want := range []string{
+		"1. [Exam 010-160 topic 1 question 1 discussion](#exam-010-160-topic-1-question-1-discussion)",
+		"2. [Exam 010-160 topic 1 question 1 discussion](#exam-010-160-topic-1-question-1-discussion-1)",
+		"[Back to top](#exam-topics-questions)",
+		"## Answer Key",
+		"| 2 | [Exam 010-160 topic 1 question 1 discussion](#exam-010-160-topic-1-question-1-discussion-1) | B |  |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the markdown:\n%s", want, out)
+		}
+	}
+
+	loaded, err := export.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, questions) {
+		t.Errorf("Expected the table of contents and answer key to be skipped when loading, got %+v", loaded)
+	}
+}
+
+func TestMarkdownCommentsEndTheirParagraph(t *testing.T) {
+	questions := sampleQuestions()
+	questions[0].Comments = "ls is right"
+
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := export.Write(questions, path, export.Options{TOC: true, Comments: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	// Without the blank line the link would continue the comments paragraph
+	if !strings.Contains(string(raw), "Comments: ls is right\n\n[Back to top]") {
+		t.Errorf("Expected a blank line after the comments:\n%s", raw)
+	}
+}
+
+func TestMarkdownKeepsHeadingLikeLinesInTheQuestion(t *testing.T) {
+	header := htmlmd.FromString(`<p>## not a heading</p><pre class="language-bash">ls -l
+## list the files
+
+cd /tmp</pre><p>What does the script print?</p>`, "")
+	questions := []models.QuestionData{{
+		Title:        "Exam 010-160 topic 1 question 1 discussion",
+		Header:       header,
+		Answer:       "A",
+		Timestamp:    "2021-01-01",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/",
+	}}
+
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := export.Write(questions, path, export.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := export.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Header != header {
+		t.Errorf("Expected one question with its code block, got %+v", loaded)
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	anchors := utils.NewAnchors()
+	for heading, want := range map[string]string{
+		"Examtopics az 104 question #12": "examtopics-az-104-question-12",
+		"Topic 1: Q&A (Part_2)":          "topic-1-qa-part_2",
+	} {
+		if got := anchors.Add(heading); got != want {
+			t.Errorf("Anchor for %q: expected %q, got %q", heading, want, got)
+		}
+	}
+}
+
+func TestMarkdownObsidianTOC(t *testing.T) {
+	questions := sampleQuestions()
+	questions[0].Title = "Examtopics 010-160 question #1"
+
+	path := filepath.Join(t.TempDir(), "out.md")
+	if err := export.Write(questions, path, export.Options{TOC: true, TOCStyle: utils.ObsidianLinks, AnswerKey: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	out := string(raw)
+	for _, want := range []string{
+		"1. [[#Examtopics 010-160 question 1|Examtopics 010-160 question #1]]",
+		"[[#Exam Topics Questions|Back to top]]",
+		"[[#Answer Key|Answer Key]]",
+		`| 2 | [[#Exam 010-160 topic 1 question 3 discussion\|Exam 010-160 topic 1 question 3 discussion]] | B |  |`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the markdown:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "](#") {
+		t.Errorf("Expected no GitHub anchors in Obsidian links:\n%s", out)
+	}
+
+	if err := export.Write(questions, path, export.Options{TOC: true, TOCStyle: "wiki"}); err == nil {
+		t.Error("Expected an unknown toc style to be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+)
+
+const nextDataPage = `<html><head>
+<link rel="canonical" href="https://www.examtopics.com/discussions/lpi/view/102-exam-010-160-topic-1-question-3-discussion/">
+</head><body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"question":{
+ "question_text":"Which command lists files?","answer":"","answer_ET":"C",
+ "choices":{"A":"cd","B":"pwd","C":"ls"},
+ "discussion":[{"poster":"alice","content":"Selected Answer: C","upvote_count":"4","timestamp":"2023-01-02"}],
+ "url":"https://www.examtopics.com/discussions/lpi/view/102-exam-010-160-topic-1-question-3-discussion/",
+ "timestamp":"2023-01-01"}}},"page":"/discussions/[provider]/view/[slug]"}</script>
+</body></html>`
+
+func TestScrapeReadsEmbeddedPageData(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "next.html"), []byte(nextDataPage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/102-exam-010-160-topic-1-question-3/")
+	if err != nil {
+		t.Fatalf("Expected the question from the embedded data, got %v", err)
+	}
+	if data.Answer != "C" || data.Header != "Which command lists files?" {
+		t.Errorf("Expected answer C and the question text, got %+v", data)
+	}
+	if data.Title != "Exam 010-160 topic 1 question 3 discussion" {
+		t.Errorf("Expected a title built from the link, got %q", data.Title)
+	}
+	if len(data.Discussion) != 1 || data.Discussion[0].Upvotes != 4 || len(data.Votes) != 1 || data.Votes[0].Answer != "C" {
+		t.Errorf("Expected the discussion and votes to be mapped, got %+v %+v", data.Discussion, data.Votes)
+	}
+	if len(data.Questions) != 1 || !strings.Contains(data.Questions[0], "**C:** ls") {
+		t.Errorf("Expected the choices to be mapped, got %q", data.Questions)
+	}
+	if data.Origin != "mirror" {
+		t.Errorf("Expected the mirror origin, got %q", data.Origin)
+	}
+}
+
+func TestScrapeFallsBackToSelectors(t *testing.T) {
+	// Embedded data for another question must not be used
+	other := `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"questions":[
+{"answer":"A","url":"https://www.examtopics.com/discussions/lpi/view/999-exam-010-160-topic-1-question-9/"}]}}}</script></body>`
+	page := strings.Replace(savedPageHTML, "</body>", other, 1)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/")
+	if err != nil || data.Answer != "B" || len(data.Questions) != 2 {
+		t.Errorf("Expected the selectors to find answer B and 2 choices, got %+v (%v)", data, err)
+	}
+}
+
+func TestEmbeddedPageDataResolvesImages(t *testing.T) {
+	page := strings.Replace(nextDataPage, `"timestamp":"2023-01-01"`,
+		`"timestamp":"2023-01-01","question_images":["/assets/media/exam-media/04223/0000300001.png","https://img.example/b.png"]`, 1)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "next.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/102-exam-010-160-topic-1-question-3/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://www.examtopics.com/assets/media/exam-media/04223/0000300001.png\nhttps://img.example/b.png"; data.Content != want {
+		t.Errorf("Expected the images resolved against the page, got %q", data.Content)
+	}
+}
+
+func TestCachedDataIgnoresExamViewAnswer(t *testing.T) {
+	dir := t.TempDir()
+	page := `{"pageProps":{"questions":[{"question_text":"Which command lists files?","answer":"","answer_ET":"C",
+"url":"https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/"}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "010-160_1.json"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewLocalSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Answer != "" {
+		t.Errorf("Expected the cached answer to be used as is, got %q", data.Answer)
+	}
+}
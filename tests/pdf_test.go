@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+)
+
+func TestPDFExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/image1.png" {
+			http.NotFound(w, r)
+			return
+		}
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	}))
+	defer server.Close()
+
+	questions := sampleQuestions()
+	questions[0].Content = server.URL + "/image1.png " + server.URL + "/missing.png"
+
+	for _, answerKey := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "out.pdf")
+		if err := export.Write(questions, path, export.Options{AnswerKey: answerKey}); err != nil {
+			t.Fatalf("Failed writing the PDF: %v", err)
+		}
+
+		out, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(out, []byte("%PDF-")) {
+			t.Fatalf("Expected a PDF document, got %q", out[:min(len(out), 20)])
+		}
+		if !bytes.Contains(out, []byte("/Subtype /Image")) {
+			t.Errorf("Expected the question image to be embedded")
+		}
+		pages := bytes.Count(out, []byte("/Type /Page\n"))
+		if answerKey && pages != 2 || !answerKey && pages != 1 {
+			t.Errorf("Expected the answer key to add a page, got %d pages (answer key %v)", pages, answerKey)
+		}
+	}
+}
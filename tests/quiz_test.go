@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/quiz"
+)
+
+func TestQuizScoresAnswers(t *testing.T) {
+	questions := sampleQuestions()
+
+	session := quiz.NewSession(strings.NewReader("a\nc\n"), io.Discard)
+	score := session.Run(questions, 0)
+
+	if score.Correct != 1 || score.Wrong != 1 || score.Total != 2 {
+		t.Errorf("Expected 1 correct and 1 wrong answer, got %+v", score)
+	}
+}
+
+func TestQuizSelectIsRepeatable(t *testing.T) {
+	questions := sampleQuestions()
+
+	first := quiz.Select(questions, quiz.Filter{Sample: 1, Seed: 7})
+	second := quiz.Select(sampleQuestions(), quiz.Filter{Sample: 1, Seed: 7})
+	if len(first) != 1 || first[0].QuestionLink != second[0].QuestionLink {
+		t.Errorf("Expected the same seed to pick the same question, got %v and %v", first, second)
+	}
+
+	if ranged := quiz.Select(questions, quiz.Filter{From: 2, To: 3}); len(ranged) != 1 || ranged[0].Answer != "B" {
+		t.Errorf("Expected only question 3 in range 2-3, got %+v", ranged)
+	}
+}
+
+func TestQuizKeepsQuestionLayout(t *testing.T) {
+	questions := sampleQuestions()[:1]
+	questions[0].Header = "You deploy:\n\n```yaml\nmetadata:\n  name: web\n```"
+
+	var out strings.Builder
+	quiz.NewSession(strings.NewReader("a\n"), &out).Run(questions, 0)
+	if !strings.Contains(out.String(), "```yaml\nmetadata:\n  name: web\n```") {
+		t.Errorf("Expected the code block to be shown as is, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"examtopics-downloader/internal/review"
+)
+
+func TestSM2Scheduling(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	card := review.NewCard("link", "010-160", 1)
+
+	card.Review(review.QualityCorrect, now)
+	card.Review(review.QualityCorrect, now)
+	if card.Interval != 6 || card.Repetitions != 2 {
+		t.Fatalf("Expected a 6 day interval after two correct answers, got %+v", card)
+	}
+
+	card.Review(review.QualityWrong, now)
+	if card.Interval != 1 || card.Lapses != 1 || card.Ease >= 2.5 {
+		t.Errorf("Expected a wrong answer to reset the interval and lower the ease, got %+v", card)
+	}
+}
+
+func TestReviewDueAndProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	store, err := review.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	questions := sampleQuestions()
+	now := time.Now()
+	store.Card("alice", questions[0].QuestionLink).Review(review.QualityCorrect, now)
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := review.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := review.Due(reopened, "alice", questions, now, 10, 10)
+	if len(due) != 1 || due[0].QuestionLink != questions[1].QuestionLink {
+		t.Errorf("Expected only the unseen question to be due, got %+v", due)
+	}
+	if due := review.Due(reopened, "bob", questions, now, 10, 10); len(due) != 2 {
+		t.Errorf("Expected every question to be new for another user, got %d", len(due))
+	}
+}
@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/search"
+)
+
+func TestSearchRanksAndHighlights(t *testing.T) {
+	questions := []models.QuestionData{
+		{Title: "Q1", Header: "Use Cloud Spanner with a multi-region configuration for availability.", Questions: []string{"A. Cloud Spanner", "B. Cloud SQL"}},
+		{Title: "Q2", Header: "Cloud Spanner in one region.", Questions: []string{"A. Bigtable"}},
+		{Title: "Q3", Header: "Pick a multi-region bucket.", Questions: []string{"A. Cloud Storage"}},
+	}
+
+	index := search.NewIndex(questions)
+	results := index.Search(search.ParseQuery("which questions mention Cloud Spanner and multi-region"), 10, search.MarkdownHighlight)
+
+	if len(results) != 1 || results[0].Question.Title != "Q1" {
+		t.Fatalf("Expected only Q1 to match every term, got %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, "**multi-region**") {
+		t.Errorf("Expected the phrase to be highlighted, got %q", results[0].Snippet)
+	}
+
+	if results := index.Search(search.ParseQuery("spanner"), 10, search.MarkdownHighlight); len(results) != 2 || results[0].Question.Title != "Q1" {
+		t.Errorf("Expected Q1 to outrank Q2 for 'spanner', got %+v", results)
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+)
+
+func TestExampleSelectorsMatchDefaults(t *testing.T) {
+	profile, err := fetch.LoadSelectors("../examples/selectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile != fetch.DefaultSelectors {
+		t.Errorf("Expected examples/selectors.json to list the built-in selectors, got %+v", profile)
+	}
+}
+
+func TestSelectorOverrideAndLayoutError(t *testing.T) {
+	t.Cleanup(func() { fetch.SetSelectors(fetch.DefaultSelectors) })
+
+	// The answer moved to a new class
+	page := strings.Replace(savedPageHTML, `class="correct-answer"`, `class="suggested-answer"`, 1)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := "https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/"
+
+	_, err := fetch.NewMirrorSource(dir).FetchQuestion(link)
+	var layoutErr *fetch.LayoutError
+	if !errors.Is(err, fetch.ErrLayoutChanged) || !errors.As(err, &layoutErr) || layoutErr.Field != "answer" {
+		t.Fatalf("Expected a layout error for the answer selector, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "site layout changed") || !strings.Contains(err.Error(), ".correct-answer") {
+		t.Errorf("Expected the error to name the selector, got %q", err)
+	}
+
+	override := filepath.Join(t.TempDir(), "selectors.json")
+	if err := os.WriteFile(override, []byte(`{"version": 1, "answer": ".suggested-answer"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := fetch.LoadSelectors(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Title != fetch.DefaultSelectors.Title || profile.Answer != ".suggested-answer" {
+		t.Fatalf("Expected only the answer selector to be overridden, got %+v", profile)
+	}
+	fetch.SetSelectors(profile)
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion(link)
+	if err != nil || data.Answer != "B" {
+		t.Errorf("Expected the override to find answer B, got %+v (%v)", data, err)
+	}
+}
+
+func TestImageAnswerIsNotALayoutError(t *testing.T) {
+	// HOTSPOT answers are an image inside the answer element
+	page := strings.Replace(savedPageHTML, `<span class="correct-answer">B</span>`,
+		`<span class="correct-answer"><img src="/assets/media/exam-media/04223/0000200002.png"></span>`, 1)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/")
+	if err != nil {
+		t.Fatalf("Expected the image answer page to be read, got %v", err)
+	}
+	if data.Answer != "" || data.Title != "Exam 010-160 topic 1 question 2 discussion" {
+		t.Errorf("Expected the question with an empty answer, got %+v", data)
+	}
+}
+
+func TestScrapedAnswerKeepsEveryLetter(t *testing.T) {
+	page := strings.Replace(savedPageHTML, `<span class="correct-answer">B</span>`, `<span class="correct-answer">BD</span>`, 1)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fetch.NewMirrorSource(dir).FetchQuestion("https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/")
+	if err != nil || data.Answer != "BD" {
+		t.Errorf("Expected both answer letters, got %+v (%v)", data, err)
+	}
+}
+
+func TestCrawlStopsWhenMostPagesChangedLayout(t *testing.T) {
+	pages := map[string]string{
+		"/discussions/lpi/": `<div class="discussion-list-page-indicator">Page <strong>1</strong> of <strong>1</strong></div>`,
+		"/discussions/lpi/1": `<a href="/discussions/lpi/view/1-exam-010-160-topic-1-question-1-discussion/">q1</a>
+<a href="/discussions/lpi/view/2-exam-010-160-topic-1-question-2-discussion/">q2</a>`,
+		// The answer moved to a class the built-in selectors don't know
+		"/discussions/lpi/view/1-exam-010-160-topic-1-question-1-discussion/": `<h1>Question 1</h1><span class="suggested-answer">A</span>`,
+		"/discussions/lpi/view/2-exam-010-160-topic-1-question-2-discussion/": `<h1>Question 2</h1><span class="suggested-answer">B</span>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html><body>" + page + "</body></html>"))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	previous := fetch.SetHTTPClient(&http.Client{Transport: rewriteTransport{target}})
+	defer fetch.SetHTTPClient(previous)
+
+	questions, err := fetch.ScrapeAllPages("lpi", "010-160")
+	if !errors.Is(err, fetch.ErrLayoutChanged) || questions != nil {
+		t.Errorf("Expected the crawl to stop with a layout error, got %v (%d questions)", err, len(questions))
+	}
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+const cachedPageJSON = `{"pageProps":{"questions":[
+{"id":"1","question_text":"First question?","answer":"A","choices":{"A":"yes","B":"no"},
+ "url":"https://www.examtopics.com/discussions/lpi/view/100-exam-010-160-topic-1-question-1-discussion/","timestamp":"2021-01-01"}
+]}}`
+
+const savedPageHTML = `<html><head>
+<link rel="canonical" href="https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2-discussion/">
+</head><body><h1>Exam 010-160 topic 1 question 2 discussion</h1>
+<div class="question-discussion-header">Question #: 2</div>
+<ul><li class="multi-choice-item">A. one</li><li class="multi-choice-item">B. two</li></ul>
+<span class="correct-answer">B</span></body></html>`
+
+func TestSourceChainFallsBackPerQuestion(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(localDir, "Lpi"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(localDir, "Lpi", "010-160_1.json"), []byte(cachedPageJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mirrorDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(mirrorDir, "saved.html"), []byte(savedPageHTML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := fetch.NewChain("local,mirror", fetch.SourceOptions{LocalDir: localDir, MirrorDir: mirrorDir})
+	if err != nil {
+		t.Fatalf("Unexpected error building chain: %v", err)
+	}
+
+	links, err := chain.ListQuestions("lpi", "010-160")
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected 1 question listed from the local source, got %v (%v)", links, err)
+	}
+
+	cached, err := chain.FetchQuestion(links[0])
+	if err != nil || cached.Answer != "A" {
+		t.Fatalf("Expected cached question with answer A, got %+v (%v)", cached, err)
+	}
+
+	// Missing from the local data, so it must come from the mirror
+	saved, err := chain.FetchQuestion("https://www.examtopics.com/discussions/lpi/view/101-exam-010-160-topic-1-question-2/")
+	if err != nil || saved.Answer != "B" {
+		t.Fatalf("Expected mirrored question with answer B, got %+v (%v)", saved, err)
+	}
+
+	if _, err := chain.FetchQuestion("https://www.examtopics.com/discussions/lpi/view/999-exam-010-160-topic-1-question-9/"); err == nil {
+		t.Errorf("Expected an error for a question no source has")
+	}
+}
+
+// fakeSource is an in-memory source standing in for the cache or the scraper
+type fakeSource struct {
+	name      string
+	links     []string
+	questions map[string]models.QuestionData
+	fetched   []string
+	listed    bool
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) ListExams(providerName string) ([]string, error) { return nil, nil }
+
+func (s *fakeSource) ListQuestions(providerName, grepStr string) ([]string, error) {
+	s.listed = true
+	return s.links, nil
+}
+
+func (s *fakeSource) FetchQuestion(link string) (*models.QuestionData, error) {
+	s.fetched = append(s.fetched, link)
+	data, ok := s.questions[utils.NormalizeQuestionURL(link)]
+	if !ok {
+		return nil, fetch.ErrQuestionNotFound
+	}
+	return &data, nil
+}
+
+func newFakeSource(name string, answers map[string]string) *fakeSource {
+	s := &fakeSource{name: name, questions: make(map[string]models.QuestionData)}
+	for link, answer := range answers {
+		s.links = append(s.links, link)
+		s.questions[utils.NormalizeQuestionURL(link)] = models.QuestionData{Title: link, QuestionLink: link, Answer: answer, Origin: name}
+	}
+	sort.Strings(s.links)
+	return s
+}
+
+func TestChainScrapesQuestionsMissingFromCache(t *testing.T) {
+	const (
+		q1 = "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/"
+		q2 = "https://www.examtopics.com/discussions/lpi/view/2-exam-010-160-topic-1-question-2/"
+		q3 = "https://www.examtopics.com/discussions/lpi/view/3-exam-010-160-topic-1-question-3/"
+	)
+	// The cache lists q2 without having its data
+	cache := newFakeSource("cache", map[string]string{q1: "A", q3: "C"})
+	cache.links = []string{q1, q2, q3}
+	scrape := newFakeSource("scrape", map[string]string{q1: "X", q2: "B", q3: "X"})
+	chain := &fetch.Chain{Sources: []fetch.Source{cache, scrape}}
+
+	questions := chain.FetchAll("lpi", "010-160")
+	var got []string
+	for _, question := range questions {
+		got = append(got, question.Origin+":"+question.Answer)
+	}
+	if strings.Join(got, " ") != "cache:A scrape:B cache:C" {
+		t.Errorf("Expected q2 to be scraped and the rest to come from the cache in order, got %v", got)
+	}
+	if len(scrape.fetched) != 1 || scrape.fetched[0] != q2 {
+		t.Errorf("Expected only q2 to be scraped, got %v", scrape.fetched)
+	}
+	if scrape.listed {
+		t.Error("Expected the scraper not to crawl the listing when the cache lists the exam")
+	}
+}
+
+func TestHybridPagesUseTheChain(t *testing.T) {
+	const (
+		q1 = "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/"
+		q2 = "https://www.examtopics.com/discussions/lpi/view/2-exam-010-160-topic-1-question-2/"
+	)
+	local := newFakeSource("local", map[string]string{q2: "B"})
+	scrape := newFakeSource("scrape", map[string]string{q1: "A", q2: "X"})
+
+	questions := fetch.GetHybridPages(&fetch.Chain{Sources: []fetch.Source{local, scrape}}, "lpi", "010-160")
+	if len(questions) != 2 || questions[0].Origin != "scrape" || questions[1].Origin != "local" {
+		t.Errorf("Expected q1 scraped and q2 from the local source, got %+v", questions)
+	}
+}
+
+func TestHybridAddsQuestionsOnlyTheSiteLists(t *testing.T) {
+	const (
+		q1 = "https://www.examtopics.com/discussions/lpi/view/1-exam-010-160-topic-1-question-1/"
+		q2 = "https://www.examtopics.com/discussions/lpi/view/2-exam-010-160-topic-1-question-2/"
+		q3 = "https://www.examtopics.com/discussions/lpi/view/3-exam-010-160-topic-1-question-3/"
+	)
+	links := func(questions []models.QuestionData) string {
+		var got []string
+		for _, question := range questions {
+			got = append(got, question.Origin+":"+question.QuestionLink)
+		}
+		return strings.Join(got, " ")
+	}
+	newChain := func() *fetch.Chain {
+		cache := newFakeSource("cache", map[string]string{q1: "A", q3: "C"})
+		scrape := newFakeSource("scrape", map[string]string{q1: "X", q2: "B", q3: "X"})
+		return &fetch.Chain{Sources: []fetch.Source{cache, scrape}}
+	}
+
+	if got, want := links(newChain().FetchAll("lpi", "010-160")), "cache:"+q1+" cache:"+q3; got != want {
+		t.Errorf("Expected a plain run to keep to the cache listing:\n got %s\nwant %s", got, want)
+	}
+	if got, want := links(fetch.GetHybridPages(newChain(), "lpi", "010-160")), "cache:"+q1+" scrape:"+q2+" cache:"+q3; got != want {
+		t.Errorf("Expected -hybrid to scrape the question missing from the cache:\n got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+)
+
+func splitSample() []models.QuestionData {
+	questions := sampleQuestions()
+	questions[0].Page = 1
+	questions[1].Page = 2
+	return append(questions, models.QuestionData{
+		Title:        "Exam 010-160 topic 2 question 1 discussion",
+		Header:       "Which file holds user accounts?",
+		Questions:    []string{"A. /etc/passwd", "B. /etc/hosts"},
+		Answer:       "A",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/200-exam-010-160-topic-2-question-1-discussion/",
+	})
+}
+
+func TestSplitQuestions(t *testing.T) {
+	questions := splitSample()
+
+	cases := map[string][]string{
+		"topic": {"Topic 1", "Topic 2"},
+		"page":  {"Page 1", "Page 2", "Other questions"},
+		"2":     {"Questions 1-2", "Questions 3-3"},
+	}
+	for mode, want := range cases {
+		parts, err := export.SplitQuestions(questions, mode)
+		if err != nil {
+			t.Fatalf("Split by %s failed: %v", mode, err)
+		}
+		var names []string
+		for _, part := range parts {
+			names = append(names, part.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("Split by %s: expected %v, got %v", mode, want, names)
+		}
+	}
+
+	if _, err := export.SplitQuestions(questions, "chapter"); err == nil {
+		t.Error("Expected an error for an unknown split mode")
+	}
+}
+
+func TestSplitWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := export.Write(splitSample(), filepath.Join(dir, "out.md"), export.Options{Split: "topic"}); err != nil {
+		t.Fatal(err)
+	}
+	topic1, err := export.Load(filepath.Join(dir, "out-topic-1.md"))
+	if err != nil || len(topic1) != 2 {
+		t.Fatalf("Expected 2 questions in topic 1, got %d (%v)", len(topic1), err)
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "out-index.md"))
+	if !strings.Contains(string(index), "[Topic 2](out-topic-2.md) | 1 |") {
+		t.Errorf("Expected the index to link topic 2:\n%s", index)
+	}
+
+	if err := export.Write(splitSample(), filepath.Join(dir, "out.json"), export.Options{Split: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	var parts []export.Part
+	raw, _ := os.ReadFile(filepath.Join(dir, "out-index.json"))
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) != 2 || parts[1].File != "out-part-2.json" {
+		t.Errorf("Unexpected JSON index %s (%v)", raw, err)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteExportKeepsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.db")
+	opts := export.Options{Comments: true}
+
+	questions := sampleQuestions()
+	questions[0].Discussion = []models.Comment{{Poster: "alice", Content: "A is right", Upvotes: 1, SelectedAnswer: "A"}}
+	questions[0].Votes = []models.Vote{{Answer: "A", Count: 1, MostVoted: true}}
+	if err := export.Write(questions, path, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next export changes an answer, drops a comment's question text and adds a comment
+	questions[0].Answer = "B"
+	questions[0].Discussion = []models.Comment{
+		{Poster: "alice", Content: "A is right", Upvotes: 5, SelectedAnswer: "A"},
+		{Poster: "bob", Content: "B actually", Upvotes: 2, SelectedAnswer: "B"},
+	}
+	if err := export.Write(questions, path, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	count := func(query string, args ...any) int {
+		var n int
+		if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+
+	if n := count("SELECT COUNT(*) FROM exports"); n != 2 {
+		t.Errorf("Expected 2 exports, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM questions"); n != 2 {
+		t.Errorf("Expected the questions to be upserted, got %d rows", n)
+	}
+	if n := count("SELECT COUNT(*) FROM exams WHERE provider = 'lpi' AND slug = '010-160'"); n != 1 {
+		t.Errorf("Expected one exam, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM choices"); n != 4 {
+		t.Errorf("Expected 4 choices, got %d", n)
+	}
+
+	var answers string
+	err = db.QueryRow(`SELECT group_concat(h.answer, ',') FROM answer_history h
+		JOIN questions q ON q.id = h.question_id WHERE q.number = 1 ORDER BY h.id`).Scan(&answers)
+	if err != nil || answers != "A,B" {
+		t.Errorf("Expected the answer history A,B, got %q (%v)", answers, err)
+	}
+	if n := count("SELECT COUNT(*) FROM answer_history"); n != 3 {
+		t.Errorf("Expected unchanged answers not to add history, got %d rows", n)
+	}
+	if n := count("SELECT upvotes FROM comments WHERE poster = 'alice'"); n != 5 {
+		t.Errorf("Expected the comment's upvotes to be refreshed, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM comments"); n != 2 {
+		t.Errorf("Expected 2 comments, got %d", n)
+	}
+}
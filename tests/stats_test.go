@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/stats"
+)
+
+func TestSummarize(t *testing.T) {
+	questions := sampleQuestions()
+	questions[0].Origin = "cache"
+	questions[1].Origin = "scrape"
+	questions = append(questions, models.QuestionData{
+		Header:       "HOTSPOT - Select the answer in the answer area.",
+		Content:      "https://img.examtopics.com/010-160/image1.png",
+		Answer:       "",
+		QuestionLink: "https://www.examtopics.com/discussions/lpi/view/7-exam-010-160-topic-2-question-2/",
+		Timestamp:    "Sept. 5, 2022, noon",
+	})
+
+	summary := stats.Summarize(questions)
+	if summary.Questions != 3 || summary.WithImages != 1 || summary.WithAnswer != 2 {
+		t.Errorf("Unexpected counts: %+v", summary)
+	}
+	if len(summary.Topics) != 2 || summary.Topics[0].Questions != 2 || len(summary.Topics[0].Missing) != 1 || summary.Topics[0].Missing[0] != 2 {
+		t.Errorf("Expected topic 1 to miss question 2, got %+v", summary.Topics)
+	}
+	if summary.Types[stats.TypeHotspot] != 1 || summary.AnswerLetters["A"] != 1 || summary.AnswerLetters["B"] != 1 {
+		t.Errorf("Unexpected types or answers: %v %v", summary.Types, summary.AnswerLetters)
+	}
+	if summary.Origins["cache"] != 1 || summary.Origins["scrape"] != 1 || summary.Origins["unknown"] != 1 {
+		t.Errorf("Unexpected origins: %v", summary.Origins)
+	}
+	if summary.Oldest != questions[0].Timestamp || summary.Newest != "Sept. 5, 2022, noon" || summary.UnparsedDates != 0 {
+		t.Errorf("Unexpected timestamps: oldest %q, newest %q, unparsed %d", summary.Oldest, summary.Newest, summary.UnparsedDates)
+	}
+}
+
+func TestQuestionTypeOfScrapedQuestions(t *testing.T) {
+	header := "Actual exam question from Microsoft's AZ-104\nQuestion #: 3\nTopic #: 1"
+	for content, want := range map[string]string{
+		"HOTSPOT -\nYou have an Azure subscription.\n![](https://img.examtopics.com/az-104/image1.png)": stats.TypeHotspot,
+		"DRAG DROP -\nYou need to move the resources.":                                                  stats.TypeDragDrop,
+	} {
+		question := models.QuestionData{Header: header, Content: content}
+		if got := stats.QuestionType(question); got != want {
+			t.Errorf("Expected %q for %q, got %q", want, content, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+)
+
+func TestTemplateOutput(t *testing.T) {
+	questions := sampleQuestions()
+	questions[1].Votes = []models.Vote{{Answer: "B", Count: 3, MostVoted: true}, {Answer: "A", Count: 1}}
+	questions[1].Discussion = []models.Comment{{Poster: "alice", Content: "<b>cd</b> it is", Upvotes: 2}}
+
+	for _, name := range []string{"flashcards.md.tmpl", "study.html"} {
+		path := filepath.Join(t.TempDir(), "out")
+		opts := export.Options{Comments: true, Template: filepath.Join("..", "examples", "templates", name)}
+		if err := export.Write(questions, path, opts); err != nil {
+			t.Fatalf("Failed rendering %s: %v", name, err)
+		}
+
+		out, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), "010-160") || !strings.Contains(string(out), "Which command changes directory?") {
+			t.Errorf("%s is missing the exam or question text:\n%s", name, out)
+		}
+		if name == "flashcards.md.tmpl" && !strings.Contains(string(out), "community: B (75%)") {
+			t.Errorf("Expected the community answer in %s:\n%s", name, out)
+		}
+		if name == "study.html" {
+			if !strings.Contains(string(out), `<li class="answer">cd</li>`) || !strings.Contains(string(out), "&lt;b&gt;cd&lt;/b&gt;") {
+				t.Errorf("Expected the marked answer and escaped comments in %s:\n%s", name, out)
+			}
+		}
+	}
+}
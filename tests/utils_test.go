@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"examtopics-downloader/internal/fetch"
+	"examtopics-downloader/internal/models"
+	"examtopics-downloader/internal/utils"
+)
+
+func TestMatchExamSlugs(t *testing.T) {
+	slugs := []string{"az-100", "az-104", "az-900", "professional-cloud-architect", "professional-cloud-devops-engineer"}
+
+	cases := map[string][]string{
+		"AZ 104":                      {"az-104"},
+		"az-10":                       {"az-100", "az-104"},
+		"devops":                      {"professional-cloud-devops-engineer"},
+		"profesional-cloud-architect": {"professional-cloud-architect"},
+		"ccna":                        nil,
+	}
+
+	for query, expected := range cases {
+		got := utils.MatchExamSlugs(query, slugs)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("MatchExamSlugs(%q) = %v, expected %v", query, got, expected)
+		}
+	}
+}
+
+func TestMatchesExam(t *testing.T) {
+	link := "/discussions/microsoft/view/12345-exam-az-104-topic-2-question-7-discussion/"
+	if !utils.MatchesExam(link, "az-104") {
+		t.Errorf("Expected %s to match exam az-104", link)
+	}
+	if utils.MatchesExam(link, "az-10") {
+		t.Errorf("Expected %s not to match exam az-10", link)
+	}
+
+	ref, ok := utils.ParseQuestionLink(link)
+	if !ok || ref.Provider != "microsoft" || ref.ID != 12345 || ref.Topic != 2 || ref.Number != 7 {
+		t.Errorf("Unexpected question ref %+v for %s", ref, link)
+	}
+}
+
+func TestDeduplicateAndSortQuestionsByLink(t *testing.T) {
+	const base = "https://www.examtopics.com/discussions/lpi/view/"
+	data := []models.QuestionData{
+		{Title: "t2q1", QuestionLink: base + "4-exam-010-160-topic-2-question-1/"},
+		{Title: "t1q10", QuestionLink: base + "3-exam-010-160-topic-1-question-10/"},
+		{Title: "unparseable", QuestionLink: "https://example.com/question"},
+		{Title: "t1q2", QuestionLink: base + "2-exam-010-160-topic-1-question-2-discussion/"},
+		{Title: "t1q2 again", QuestionLink: "/discussions/lpi/view/2-exam-010-160-topic-1-question-2"},
+	}
+
+	unique := utils.DeduplicateQuestions(data)
+	if len(unique) != 4 || unique[3].Title != "t1q2" {
+		t.Fatalf("Expected the repeated link to be dropped keeping the first, got %+v", unique)
+	}
+
+	var titles []string
+	for _, question := range utils.SortQuestionsByLink(unique) {
+		titles = append(titles, question.Title)
+	}
+	want := []string{"t1q2", "t1q10", "t2q1", "unparseable"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("Expected %v, got %v", want, titles)
+	}
+}
+
+func TestMatchExam(t *testing.T) {
+	slugs := []string{"az-100", "az-104", "az-900"}
+
+	if slug, err := fetch.MatchExam("microsoft", "AZ 104", slugs); err != nil || slug != "az-104" {
+		t.Errorf("Expected az-104, got %q (%v)", slug, err)
+	}
+
+	var matchErr *fetch.ExamMatchError
+	_, err := fetch.MatchExam("microsoft", "az-10", slugs)
+	if !errors.As(err, &matchErr) || !matchErr.Ambiguous || len(matchErr.Candidates) != 2 {
+		t.Errorf("Expected an ambiguous match, got %v", err)
+	}
+
+	_, err = fetch.MatchExam("microsoft", "sc-200", slugs)
+	if !errors.As(err, &matchErr) || matchErr.Ambiguous {
+		t.Errorf("Expected no match, got %v", err)
+	}
+}
+
+func TestParseSelectedAnswer(t *testing.T) {
+	tests := map[string]string{
+		"Selected Answer: BD":                           "BD",
+		"selected answer: C, the others are wrong":      "C",
+		"The selected answer is wrong, it should be A":  "",
+		"My selected answer was none of them":           "",
+		"Selected Answer: Because the docs say so":      "",
+		"I agree with the selected answer: it is right": "",
+	}
+	for text, want := range tests {
+		if got := utils.ParseSelectedAnswer(text); got != want {
+			t.Errorf("ParseSelectedAnswer(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/export"
+	"examtopics-downloader/internal/models"
+)
+
+func TestVaultExport(t *testing.T) {
+	questions := sampleQuestions()
+	questions[1].Header = questions[0].Header
+	questions[1].Votes = []models.Vote{{Answer: "A", Count: 9, MostVoted: true}, {Answer: "B", Count: 1}}
+
+	dir := filepath.Join(t.TempDir(), "vault")
+	opts := export.Options{Format: "obsidian"}
+	if err := export.Write(questions, dir, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	first := filepath.Join(dir, "010-160 T1 Q1.md")
+	raw, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := string(raw)
+	for _, want := range []string{"---\nexam: 010-160\n", "topic: 1\n", "number: 1\n", `answer: A`, "Similar questions: [[010-160 T1 Q3]]", "## Notes"} {
+		if !strings.Contains(note, want) {
+			t.Errorf("Expected %q in the note:\n%s", want, note)
+		}
+	}
+
+	second, _ := os.ReadFile(filepath.Join(dir, "010-160 T1 Q3.md"))
+	if !strings.Contains(string(second), "community_answer: A") || !strings.Contains(string(second), "- disputed") {
+		t.Errorf("Expected the disputed community answer in the front matter:\n%s", second)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(dir, "010-160.md"))
+	if !strings.Contains(string(index), "- [[010-160 T1 Q1]] A") {
+		t.Errorf("Expected the index to link the notes:\n%s", index)
+	}
+
+	// Notes written by the user survive a re-export
+	os.WriteFile(first, []byte(note+"Remember: ls lists files.\n"), 0o644)
+	if err := export.Write(questions, dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = os.ReadFile(first)
+	if !strings.HasSuffix(string(raw), "## Notes\n\nRemember: ls lists files.\n") || strings.Count(string(raw), "## Notes") != 1 {
+		t.Errorf("Expected the user's notes to be kept:\n%s", raw)
+	}
+}
+
+func TestVaultExportNamesNotesPerExam(t *testing.T) {
+	questions := sampleQuestions()
+	questions[1].Header = "Which AWS service stores objects?"
+	questions[1].QuestionLink = "https://www.examtopics.com/discussions/amazon/view/7-exam-aws-certified-cloud-practitioner-topic-1-question-3-discussion/"
+
+	dir := filepath.Join(t.TempDir(), "vault")
+	if err := export.Write(questions, dir, export.Options{Format: "obsidian"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"010-160 T1 Q1.md", "AWS-CERTIFIED-CLOUD-PRACTITIONER T1 Q3.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected the note %s: %v", name, err)
+		}
+	}
+
+	lpi, _ := os.ReadFile(filepath.Join(dir, "010-160.md"))
+	aws, _ := os.ReadFile(filepath.Join(dir, "AWS-CERTIFIED-CLOUD-PRACTITIONER.md"))
+	if !strings.Contains(string(lpi), "1 questions.") || !strings.Contains(string(lpi), "[[010-160 T1 Q1]]") || strings.Contains(string(lpi), "AWS") {
+		t.Errorf("Expected the 010-160 index to list only its own question:\n%s", lpi)
+	}
+	if !strings.Contains(string(aws), "[[AWS-CERTIFIED-CLOUD-PRACTITIONER T1 Q3]]") {
+		t.Errorf("Expected an index for the second exam:\n%s", aws)
+	}
+}
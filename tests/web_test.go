@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"examtopics-downloader/internal/web"
+)
+
+func TestWebUI(t *testing.T) {
+	server, err := web.NewServer("LPI", sampleQuestions())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	get := func(path string) string {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := get("/?q=directory"); !strings.Contains(body, "1 questions") || !strings.Contains(body, "changes directory") {
+		t.Errorf("Expected search to find only the cd question, got:\n%s", body)
+	}
+	if body := get("/questions/1"); !strings.Contains(body, "Suggested answer") {
+		t.Errorf("Expected the question page to hold the answer, got:\n%s", body)
+	}
+
+	resp, err := http.PostForm(ts.URL+"/practice", url.Values{"id": {"1", "2"}, "answer-1": {"A"}, "answer-2": {"A"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Score: 1/2") {
+		t.Errorf("Expected a score of 1/2, got:\n%s", body)
+	}
+}